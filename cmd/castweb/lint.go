@@ -0,0 +1,54 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/claes/ytplv/internal/browse"
+)
+
+// runLint implements `castweb lint [-json] [-path SUBDIR] ROOT`. It prints one
+// line per diagnostic and returns the process exit code: 0 when the library
+// is clean, 1 when problems were found, 2 on usage or I/O errors.
+func runLint(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("lint", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	var root, sub string
+	var asJSON bool
+	fs.StringVar(&root, "root", "", "root directory containing .strm/.nfo hierarchy (required)")
+	fs.StringVar(&sub, "path", "", "only check this subdirectory of root")
+	fs.BoolVar(&asJSON, "json", false, "print diagnostics as a JSON array")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if root == "" && fs.NArg() > 0 {
+		root = fs.Arg(0)
+	}
+	if root == "" {
+		fmt.Fprintln(stderr, "usage: castweb lint [-json] [-path SUBDIR] ROOT")
+		return 2
+	}
+	diags, err := browse.Lint(root, sub)
+	if err != nil {
+		fmt.Fprintln(stderr, "lint:", err)
+		return 2
+	}
+	if asJSON {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		if diags == nil {
+			diags = []browse.Diagnostic{}
+		}
+		_ = enc.Encode(diags)
+	} else {
+		for _, d := range diags {
+			fmt.Fprintf(stdout, "%s: %s: %s\n", d.Path, d.Code, d.Reason)
+		}
+	}
+	if len(diags) > 0 {
+		return 1
+	}
+	return 0
+}
@@ -7,26 +7,38 @@ import (
     nethttp "net/http"
     "os"
     "os/signal"
+    "path/filepath"
     "syscall"
     "time"
 
+    "github.com/claes/ytplv/internal/cast"
     apphttp "github.com/claes/ytplv/internal/http"
+    "github.com/claes/ytplv/internal/library"
 )
 
 func main() {
     // Configure structured logging to stderr
     slog.SetDefault(slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{})))
 
+    // Subcommands
+    if len(os.Args) > 1 && os.Args[1] == "lint" {
+        os.Exit(runLint(os.Args[2:], os.Stdout, os.Stderr))
+    }
+
     // Flags
     var root string
     var ytcastDevice string
     var port string
     var svtEndpoint string
     var statePath string
+    var recentDays int
+    var castConfig string
 	flag.StringVar(&root, "root", "", "root directory containing .strm/.nfo hierarchy (required)")
-    flag.StringVar(&ytcastDevice, "ytcast", "", "ytcast device id to cast to (optional)")
+    flag.StringVar(&ytcastDevice, "ytcast", "", "YouTube device to cast to: a paired screen's name or ID (optional)")
     flag.StringVar(&statePath, "state", "/var/lib/castweb", "directory for persistent state (state.json)")
     flag.StringVar(&svtEndpoint, "svtplay-endpoint", "http://localhost:18492/play", "endpoint to call for SVT URLs (GET with ?url=)")
+	flag.StringVar(&castConfig, "cast-config", "", "JSON file defining cast backends and routes (optional; default the YouTube lounge client and the SVT endpoint)")
+	flag.IntVar(&recentDays, "recent-days", apphttp.DefaultRecentDays, "days covered by the Recently added and Continue watching folders")
 	flag.StringVar(&port, "port", "", "port to listen on (required or set PORT env)")
 	flag.Parse()
 	if root == "" {
@@ -51,7 +63,52 @@ func main() {
         os.Exit(1)
     }
 
-	mux := apphttp.NewServer(root, ytcastDevice, statePath, svtEndpoint)
+	var casters *cast.Router
+	if castConfig != "" {
+		r, err := cast.LoadConfig(castConfig)
+		if err != nil {
+			slog.Error("invalid cast config", "path", castConfig, "err", err)
+			os.Exit(1)
+		}
+		casters = r
+	}
+
+	// Library index: cache listings in memory, invalidated by filesystem events.
+	// Parsed items are saved in the state directory and revalidated by mtime
+	// at startup, so only files changed since the last run are re-parsed.
+	// Without a state directory the index is only kept in memory.
+	lib := library.NewIndex(root)
+	var indexPath string
+	if statePath != "" {
+		indexPath = filepath.Join(statePath, library.IndexFile)
+		if err := lib.Load(indexPath); err != nil {
+			slog.Warn("failed to load library index, rescanning", "path", indexPath, "err", err)
+		}
+	}
+	if err := lib.Watch(); err != nil {
+		slog.Warn("library watch unavailable, rescanning on every request", "err", err)
+	}
+	defer lib.Close()
+	go func() {
+		start := time.Now()
+		n, err := lib.Warm()
+		if err != nil {
+			slog.Warn("library warm-up failed", "err", err)
+			return
+		}
+		slog.Info("library index ready", "dirs", n, "elapsed", time.Since(start))
+		saveIndex(lib, indexPath)
+	}()
+
+	mux := apphttp.New(apphttp.Config{
+		Root:         root,
+		YtcastDevice: ytcastDevice,
+		StateDir:     statePath,
+		SVTEndpoint:  svtEndpoint,
+		RecentDays:   recentDays,
+		Casters:      casters,
+		Index:        lib,
+	})
 
 	addr := ":" + port
 
@@ -91,5 +148,17 @@ func main() {
         slog.Warn("graceful shutdown failed", "err", err)
         _ = srv.Close()
     }
+    saveIndex(lib, indexPath)
     slog.Info("server stopped")
 }
+
+// saveIndex persists the library index, logging failures. An empty path
+// (no state directory) saves nothing.
+func saveIndex(lib *library.Index, path string) {
+    if path == "" {
+        return
+    }
+    if err := lib.Save(path); err != nil {
+        slog.Warn("failed to save library index", "path", path, "err", err)
+    }
+}
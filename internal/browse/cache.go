@@ -0,0 +1,162 @@
+package browse
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/claes/ytplv/internal/model"
+)
+
+// ItemCache memoises parsed items between scans. Entries are keyed by the
+// item's base path and validated against the path, mtime and size of every
+// file of the pair, so an item is only re-parsed when one of them changes.
+// It is safe for concurrent use.
+type ItemCache struct {
+	mu sync.Mutex
+	m  map[string]cachedItem // dir/base -> item
+}
+
+type cachedItem struct {
+	fp    string
+	v     model.Video
+	err   error
+	added time.Time // when the item was first seen, kept across re-parses
+}
+
+// NewItemCache returns an empty cache.
+func NewItemCache() *ItemCache {
+	return &ItemCache{m: make(map[string]cachedItem)}
+}
+
+// Len returns the number of cached items.
+func (c *ItemCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.m)
+}
+
+// video returns the parsed item for p, from the cache when its files are
+// unchanged, and when it was added to the library: the item's mtime when the
+// cache first saw it, so rewriting its files later does not make it new
+// again. p must have been stat'ed. A nil cache parses every time and takes
+// the mtime as added time.
+func (c *ItemCache) video(dir string, p *pair) (model.Video, time.Time, error) {
+	if c == nil {
+		v, err := p.video()
+		return v, p.mtime, err
+	}
+	key := filepath.Join(dir, p.base)
+	fp := p.fp
+	c.mu.Lock()
+	ci, ok := c.m[key]
+	c.mu.Unlock()
+	added := p.mtime
+	if ok {
+		if !ci.added.IsZero() && ci.added.Before(added) {
+			added = ci.added
+		}
+		if ci.fp == fp {
+			if !ci.added.Equal(added) {
+				ci.added = added
+				c.mu.Lock()
+				c.m[key] = ci
+				c.mu.Unlock()
+			}
+			return ci.v, added, ci.err
+		}
+	}
+	v, err := p.video()
+	c.mu.Lock()
+	c.m[key] = cachedItem{fp: fp, v: v, err: err, added: added}
+	c.mu.Unlock()
+	return v, added, err
+}
+
+// retain drops cached items of dir whose base is not in keep.
+func (c *ItemCache) retain(dir string, keep map[string]*pair) {
+	if c == nil {
+		return
+	}
+	prefix := dir + string(filepath.Separator)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key := range c.m {
+		base, ok := strings.CutPrefix(key, prefix)
+		if !ok || strings.ContainsRune(base, filepath.Separator) {
+			continue
+		}
+		if _, found := keep[base]; !found {
+			delete(c.m, key)
+		}
+	}
+}
+
+// RetainDirs drops cached items whose directory is not in dirs, such as
+// items of folders that were removed while castweb was not running.
+func (c *ItemCache) RetainDirs(dirs map[string]bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key := range c.m {
+		if !dirs[filepath.Dir(key)] {
+			delete(c.m, key)
+		}
+	}
+}
+
+// DropDir drops the cached items of dir and of every folder below it, such
+// as items of a folder removed while castweb runs.
+func (c *ItemCache) DropDir(dir string) {
+	prefix := dir + string(filepath.Separator)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key := range c.m {
+		if strings.HasPrefix(key, prefix) {
+			delete(c.m, key)
+		}
+	}
+}
+
+// persistedItem is the on-disk form of a cache entry.
+type persistedItem struct {
+	Key   string      `json:"key"`
+	FP    string      `json:"fp"`
+	Added time.Time   `json:"added,omitzero"`
+	Video model.Video `json:"video"`
+}
+
+// MarshalJSON encodes the successfully parsed items. Items that failed to
+// parse are left out and simply parsed again after loading.
+func (c *ItemCache) MarshalJSON() ([]byte, error) {
+	c.mu.Lock()
+	items := make([]persistedItem, 0, len(c.m))
+	for key, ci := range c.m {
+		if ci.err == nil {
+			items = append(items, persistedItem{Key: key, FP: ci.fp, Added: ci.added, Video: ci.v})
+		}
+	}
+	c.mu.Unlock()
+	sort.Slice(items, func(i, j int) bool { return items[i].Key < items[j].Key })
+	return json.Marshal(items)
+}
+
+// UnmarshalJSON adds the encoded items to the cache. They are validated
+// against the files like any other entry when next looked up.
+func (c *ItemCache) UnmarshalJSON(b []byte) error {
+	var items []persistedItem
+	if err := json.Unmarshal(b, &items); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.m == nil {
+		c.m = make(map[string]cachedItem, len(items))
+	}
+	for _, it := range items {
+		c.m[it.Key] = cachedItem{fp: it.FP, v: it.Video, added: it.Added}
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package browse
+
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/claes/ytplv/internal/model"
+)
+
+// sortTitleTime matches a timestamp leading a sorttitle, as written by
+// tools that make sorttitle sort by publish time.
+var sortTitleTime = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}(?:[T ]\d{2}:\d{2}(?::\d{2})?(?:Z|[+-]\d{2}:?\d{2})?)?`)
+
+// itemDate returns the date an item is listed by: the NFO premiered (or
+// aired) date, else a timestamp leading the sorttitle, else mtime, the time
+// its files were last written.
+func itemDate(v *model.Video, mtime time.Time) time.Time {
+	if t, ok := videoDate(v); ok {
+		return t
+	}
+	return mtime
+}
+
+// videoDate returns the publish date recorded in the video's metadata.
+func videoDate(v *model.Video) (time.Time, bool) {
+	if t, ok := parseDate(v.Premiered); ok {
+		return t, true
+	}
+	if m := sortTitleTime.FindString(v.SortTitle); m != "" {
+		return parseDate(m)
+	}
+	return time.Time{}, false
+}
+
+var dateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05Z0700",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+func parseDate(s string) (time.Time, bool) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return time.Time{}, false
+	}
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
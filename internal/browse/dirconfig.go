@@ -0,0 +1,235 @@
+package browse
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Per-folder files controlling how castweb presents a folder.
+const (
+	DirConfigName  = ".castweb.json"
+	IgnoreFileName = ".castwebignore"
+)
+
+// DirConfig is the content of a folder's .castweb.json.
+//
+// Title, Hidden and Icon describe the folder itself. Sort, FoldersFirst and
+// Device apply to the folder and, when Inherit is set, to every folder below
+// it that does not set them itself.
+type DirConfig struct {
+	Title        string `json:"title,omitempty"`         // display title, overrides folder.nfo
+	Sort         string `json:"sort,omitempty"`          // default SortOrder of the listing
+	FoldersFirst *bool  `json:"folders_first,omitempty"` // default folders-first toggle
+	Device       string `json:"device,omitempty"`        // cast device for items in the folder
+	Hidden       bool   `json:"hidden,omitempty"`        // leave the folder out of listings and search
+	Icon         string `json:"icon,omitempty"`          // emoji, or image path relative to the folder
+	Inherit      bool   `json:"inherit,omitempty"`       // apply Sort, FoldersFirst and Device to subfolders
+}
+
+// readDirConfig reads the .castweb.json of dir. A missing file yields a zero
+// config and no error.
+func readDirConfig(dir string) (DirConfig, error) {
+	var c DirConfig
+	b, err := os.ReadFile(filepath.Join(dir, DirConfigName))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return c, nil
+		}
+		return c, err
+	}
+	if err := json.Unmarshal(b, &c); err != nil {
+		return DirConfig{}, err
+	}
+	if c.Sort != "" {
+		if _, ok := ParseSortOrder(c.Sort); !ok {
+			return DirConfig{}, errors.New("unknown sort order " + c.Sort)
+		}
+	}
+	return c, nil
+}
+
+// loadDirConfig is readDirConfig logging and ignoring broken files, which
+// lint reports.
+func loadDirConfig(dir string) DirConfig {
+	c, err := readDirConfig(dir)
+	if err != nil {
+		slog.Warn("ignoring folder config", "dir", dir, "err", err)
+	}
+	return c
+}
+
+// LoadDirConfig returns the effective configuration of the folder rel under
+// root: its own .castweb.json, with Sort, FoldersFirst and Device filled in
+// from the nearest ancestor that sets them and has Inherit.
+func LoadDirConfig(root, rel string) DirConfig {
+	rel = cleanRel(rel)
+	dir := filepath.Join(root, rel)
+	c := loadDirConfig(dir)
+	for rel != "" {
+		rel = parentOf(rel)
+		p := loadDirConfig(filepath.Join(root, rel))
+		if !p.Inherit {
+			continue
+		}
+		if c.Sort == "" {
+			c.Sort = p.Sort
+		}
+		if c.FoldersFirst == nil {
+			c.FoldersFirst = p.FoldersFirst
+		}
+		if c.Device == "" {
+			c.Device = p.Device
+		}
+	}
+	return c
+}
+
+// ignoreRule is one pattern of a .castwebignore file.
+type ignoreRule struct {
+	base    string // slash path of the folder holding the file, relative to root
+	pattern string
+	dirOnly bool // pattern ended in "/"
+	rooted  bool // pattern contains "/": matched against the path below base
+}
+
+// ignorer decides which entries of a folder are excluded from the library.
+// Patterns are shell globs (see path.Match), one per line, with "#"
+// comments. A pattern without a slash matches entry names at any depth below
+// its .castwebignore; one with a slash matches the path relative to it. A
+// trailing slash restricts a pattern to folders.
+type ignorer struct {
+	rules []ignoreRule
+}
+
+// loadIgnorer collects the .castwebignore rules of rel and its ancestors.
+func loadIgnorer(root, rel string) *ignorer {
+	rel = filepath.ToSlash(cleanRel(rel))
+	var dirs []string
+	for d := rel; ; d = path.Dir(d) {
+		if d == "." {
+			d = ""
+		}
+		dirs = append(dirs, d)
+		if d == "" {
+			break
+		}
+	}
+	ig := &ignorer{}
+	for i := len(dirs) - 1; i >= 0; i-- {
+		ig.rules = append(ig.rules, readIgnoreFile(root, dirs[i])...)
+	}
+	return ig
+}
+
+func readIgnoreFile(root, rel string) []ignoreRule {
+	f, err := os.Open(filepath.Join(root, rel, IgnoreFileName))
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+	var rules []ignoreRule
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		r := ignoreRule{base: rel}
+		if strings.HasSuffix(line, "/") {
+			r.dirOnly = true
+			line = strings.TrimRight(line, "/")
+		}
+		if strings.Contains(line, "/") {
+			r.rooted = true
+			line = strings.TrimPrefix(line, "/")
+		}
+		if _, err := path.Match(line, ""); err != nil {
+			slog.Warn("ignoring bad pattern", "file", filepath.Join(root, rel, IgnoreFileName), "pattern", line)
+			continue
+		}
+		r.pattern = line
+		rules = append(rules, r)
+	}
+	return rules
+}
+
+// ignored reports whether the entry at rel (slash path relative to root) is
+// excluded.
+func (ig *ignorer) ignored(rel string, isDir bool) bool {
+	if ig == nil {
+		return false
+	}
+	name := path.Base(rel)
+	for _, r := range ig.rules {
+		if r.dirOnly && !isDir {
+			continue
+		}
+		target := name
+		if r.rooted {
+			below, ok := strings.CutPrefix(rel, r.base+"/")
+			if r.base == "" {
+				below, ok = rel, true
+			}
+			if !ok {
+				continue
+			}
+			target = below
+		}
+		if ok, _ := path.Match(r.pattern, target); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// filter returns the entries of the folder rel that are not ignored.
+func (ig *ignorer) filter(rel string, entries []os.DirEntry) []os.DirEntry {
+	if ig == nil || len(ig.rules) == 0 {
+		return entries
+	}
+	out := entries[:0:0]
+	for _, e := range entries {
+		if !ig.ignored(joinSlash(rel, e.Name()), e.IsDir()) {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// Ignored reports whether rel, a file or folder under root, is excluded by a
+// .castwebignore in one of its parent folders.
+func Ignored(root, rel string, isDir bool) bool {
+	rel = filepath.ToSlash(cleanRel(rel))
+	if rel == "" {
+		return false
+	}
+	return loadIgnorer(root, path.Dir(rel)).ignored(rel, isDir)
+}
+
+// IgnoredPath is like Ignored but also reports rel as excluded when any
+// folder above it is, as its contents are then out of reach by browsing.
+func IgnoredPath(root, rel string, isDir bool) bool {
+	rel = filepath.ToSlash(cleanRel(rel))
+	if Ignored(root, rel, isDir) {
+		return true
+	}
+	for dir := path.Dir(rel); dir != "." && dir != "/"; dir = path.Dir(dir) {
+		if Ignored(root, dir, true) {
+			return true
+		}
+	}
+	return false
+}
+
+func joinSlash(dir, name string) string {
+	if dir == "" {
+		return name
+	}
+	return dir + "/" + name
+}
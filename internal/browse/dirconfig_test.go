@@ -0,0 +1,109 @@
+package browse
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+const ytStrm = "plugin://plugin.video.youtube/play/?video_id=abc"
+
+func TestBuildListing_HiddenAndIgnored(t *testing.T) {
+	root := t.TempDir()
+	write(t, filepath.Join(root, "keep.strm"), ytStrm)
+	write(t, filepath.Join(root, "keep.nfo"), "<movie/>")
+	write(t, filepath.Join(root, "draft.strm"), ytStrm)
+	write(t, filepath.Join(root, "draft.nfo"), "<movie/>")
+	write(t, filepath.Join(root, "scratch", DirConfigName), `{"hidden": true}`)
+	write(t, filepath.Join(root, "tmp", "x.txt"), "")
+	write(t, filepath.Join(root, "shows", "a", "old.strm"), ytStrm)
+	write(t, filepath.Join(root, "shows", "a", "old.nfo"), "<movie/>")
+	write(t, filepath.Join(root, "shows", "a", "new.strm"), ytStrm)
+	write(t, filepath.Join(root, "shows", "a", "new.nfo"), "<movie/>")
+	write(t, filepath.Join(root, "shows", "tmp.strm"), ytStrm)
+	write(t, filepath.Join(root, "shows", "tmp.nfo"), "<movie/>")
+	write(t, filepath.Join(root, IgnoreFileName), "# comment\ndraft.*\ntmp/\n")
+	write(t, filepath.Join(root, "shows", IgnoreFileName), "a/old.*\n")
+
+	l, err := BuildListing(root, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"shows"}; !slices.Equal(l.Dirs, want) {
+		t.Fatalf("dirs = %v, want %v", l.Dirs, want)
+	}
+	if len(l.Videos) != 1 || l.Videos[0].Name != "keep" {
+		t.Fatalf("videos = %+v, want only keep", l.Videos)
+	}
+
+	// "tmp/" only matches folders, so shows/tmp.* stays
+	l, err = BuildListing(root, "shows")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(l.Videos) != 1 || l.Videos[0].Name != "tmp" {
+		t.Fatalf("videos = %+v, want only tmp", l.Videos)
+	}
+
+	// rooted patterns match the path below the ignore file's folder
+	l, err = BuildListing(root, filepath.Join("shows", "a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(l.Videos) != 1 || l.Videos[0].Name != "new" {
+		t.Fatalf("videos = %+v, want only new", l.Videos)
+	}
+
+	if !Ignored(root, "tmp", true) || Ignored(root, "shows/tmp.strm", false) {
+		t.Fatalf("unexpected Ignored results")
+	}
+	if !IgnoredPath(root, "tmp/sub", true) || !IgnoredPath(root, "tmp/sub/x.jpg", false) || IgnoredPath(root, "shows/a", true) {
+		t.Fatalf("unexpected IgnoredPath results")
+	}
+}
+
+func TestLoadDirConfig_Inherit(t *testing.T) {
+	root := t.TempDir()
+	write(t, filepath.Join(root, "kids", DirConfigName), `{"title": "Kids", "device": "kids-tv", "sort": "title", "inherit": true}`)
+	write(t, filepath.Join(root, "kids", "cartoons", DirConfigName), `{"sort": "oldest"}`)
+	write(t, filepath.Join(root, "kids", "cartoons", "old", "x.txt"), "")
+	write(t, filepath.Join(root, "archive", DirConfigName), `{"sort": "oldest"}`)
+	write(t, filepath.Join(root, "archive", "sub", "x.txt"), "")
+
+	c := LoadDirConfig(root, filepath.Join("kids", "cartoons"))
+	if c.Device != "kids-tv" || c.Sort != "oldest" || c.Title != "" {
+		t.Fatalf("kids/cartoons config = %+v", c)
+	}
+	// cartoons does not inherit, so its own sort stops there
+	c = LoadDirConfig(root, filepath.Join("kids", "cartoons", "old"))
+	if c.Device != "kids-tv" || c.Sort != "title" {
+		t.Fatalf("kids/cartoons/old config = %+v", c)
+	}
+	if c := LoadDirConfig(root, "kids"); c.Title != "Kids" || c.Sort != "title" {
+		t.Fatalf("kids config = %+v", c)
+	}
+	// without inherit the parent's settings stay in the parent
+	if c := LoadDirConfig(root, filepath.Join("archive", "sub")); c.Sort != "" {
+		t.Fatalf("archive/sub config = %+v", c)
+	}
+}
+
+func TestBuildListing_FolderTitleAndIcon(t *testing.T) {
+	root := t.TempDir()
+	write(t, filepath.Join(root, "kids", DirConfigName), `{"title": "Barn", "icon": "icon.png"}`)
+	write(t, filepath.Join(root, "misc", DirConfigName), `{"icon": "🎈"}`)
+
+	l, err := BuildListing(root, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]string{}
+	for _, e := range l.Entries {
+		if e.Folder != nil {
+			got[e.Name] = e.Folder.Title + "|" + e.Folder.Icon
+		}
+	}
+	if got["kids"] != "Barn|kids/icon.png" || got["misc"] != "|🎈" {
+		t.Fatalf("folder meta = %v", got)
+	}
+}
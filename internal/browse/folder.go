@@ -0,0 +1,98 @@
+package browse
+
+import (
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/claes/ytplv/internal/model"
+	"github.com/claes/ytplv/internal/parser"
+)
+
+// Files inside a directory that describe the directory itself, in order of preference.
+var (
+	folderNFONames   = []string{"tvshow.nfo", "folder.nfo"}
+	folderThumbNames = []string{"folder.jpg", "poster.jpg", "fanart.jpg"}
+	folderFanartName = "fanart.jpg"
+)
+
+// folderMeta reads folder-level metadata for the child directory name of dir,
+// whose .castweb.json is cfg. Relative artwork paths are returned relative to
+// dir so they can be resolved against the listing path. Returns nil if the
+// directory has no metadata.
+func folderMeta(dir, name string, cfg DirConfig) *model.Folder {
+	sub := filepath.Join(dir, name)
+	var f model.Folder
+	found := false
+	for _, n := range folderNFONames {
+		md, err := parser.ParseNFO(filepath.Join(sub, n))
+		if err != nil {
+			continue
+		}
+		f.Title = md.Title
+		f.Plot = md.Plot
+		f.ThumbURL = childRef(name, md.Thumb)
+		if len(md.Fanart) > 0 {
+			f.Fanart = childRef(name, md.Fanart[0])
+		}
+		found = true
+		break
+	}
+	if f.ThumbURL == "" {
+		for _, n := range folderThumbNames {
+			if isFile(filepath.Join(sub, n)) {
+				f.ThumbURL = path.Join(name, n)
+				found = true
+				break
+			}
+		}
+	}
+	if f.Fanart == "" && isFile(filepath.Join(sub, folderFanartName)) {
+		f.Fanart = path.Join(name, folderFanartName)
+		found = true
+	}
+	if cfg.Title != "" {
+		f.Title = cfg.Title
+		found = true
+	}
+	if cfg.Icon != "" {
+		f.Icon = cfg.Icon
+		if IsImageRef(cfg.Icon) {
+			f.Icon = childRef(name, cfg.Icon)
+		}
+		found = true
+	}
+	if !found {
+		return nil
+	}
+	return &f
+}
+
+// IsImageRef reports whether ref names an image file rather than, say, an
+// emoji used as folder icon.
+func IsImageRef(ref string) bool {
+	switch strings.ToLower(path.Ext(ref)) {
+	case ".jpg", ".jpeg", ".png", ".gif", ".svg", ".webp":
+		return true
+	}
+	return false
+}
+
+// childRef rewrites a reference found inside child directory name so that it
+// is relative to the parent. Absolute URLs are returned unchanged.
+func childRef(name, ref string) string {
+	if ref == "" {
+		return ""
+	}
+	if u, err := url.Parse(ref); err == nil && u.Scheme != "" {
+		return ref
+	}
+	return path.Join(name, filepath.ToSlash(ref))
+}
+
+func isFile(p string) bool {
+	fi, err := os.Stat(p)
+	return err == nil && fi.Mode().IsRegular()
+}
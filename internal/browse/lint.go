@@ -0,0 +1,152 @@
+package browse
+
+import (
+	"errors"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Diagnostic codes reported by Lint in addition to the skip reasons.
+const (
+	CodeMissingThumb = "missing-thumb"  // listed item without usable thumbnail
+	CodeDuplicate    = "duplicate-base" // several files compete for the same base name
+	CodeUnreadable   = "unreadable"     // directory could not be read
+	CodeBadConfig    = "bad-config"     // .castweb.json could not be used
+)
+
+// Diagnostic explains a problem with a file in the library.
+type Diagnostic struct {
+	Path   string `json:"path"` // relative to root, slash-separated
+	Code   string `json:"code"`
+	Reason string `json:"reason"`
+}
+
+// Lint walks the tree at rel under root and reports every item BuildListing
+// would skip, with the reason, plus missing thumbnails, duplicate bases and
+// unusable folder configs. Files excluded by .castwebignore are not checked.
+// Results are sorted by path and code.
+func Lint(root, rel string) ([]Diagnostic, error) {
+	start := filepath.Join(root, cleanRel(rel))
+	if !IsSubpath(root, start) {
+		return nil, os.ErrPermission
+	}
+	var out []Diagnostic
+	add := func(path, code, reason string) {
+		if r, err := filepath.Rel(root, path); err == nil {
+			path = filepath.ToSlash(r)
+		}
+		out = append(out, Diagnostic{Path: path, Code: code, Reason: reason})
+	}
+	err := filepath.WalkDir(start, func(dir string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if dir == start {
+				return err
+			}
+			add(dir, CodeUnreadable, err.Error())
+			return nil
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		rel, _ := filepath.Rel(root, dir)
+		if dir != start && Ignored(root, rel, true) {
+			return fs.SkipDir
+		}
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			add(dir, CodeUnreadable, err.Error())
+			return fs.SkipDir
+		}
+		if _, err := readDirConfig(dir); err != nil {
+			add(filepath.Join(dir, DirConfigName), CodeBadConfig, err.Error())
+		}
+		entries = loadIgnorer(root, rel).filter(filepath.ToSlash(cleanRel(rel)), entries)
+		lintDir(dir, entries, add)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].Path != out[j].Path {
+			return out[i].Path < out[j].Path
+		}
+		return out[i].Code < out[j].Code
+	})
+	return out, nil
+}
+
+func lintDir(dir string, entries []os.DirEntry, add func(path, code, reason string)) {
+	pairs := collectPairs(dir, entries)
+	folded := map[string][]string{}
+	for base, p := range pairs {
+		folded[strings.ToLower(base)] = append(folded[strings.ToLower(base)], base)
+		for _, s := range p.shadows {
+			add(s, CodeDuplicate, "another link file for "+p.base+" is used instead: "+filepath.Base(p.url))
+		}
+		if p.url != "" && p.strm != "" {
+			add(p.strm, CodeDuplicate, "ignored, "+filepath.Base(p.url)+" takes precedence")
+		}
+		if p.nfo != "" && p.strm == "" && p.url == "" && p.dms == "" && isFolderNFO(p.nfo) {
+			continue // tvshow.nfo / folder.nfo describe the directory
+		}
+		v, err := p.video()
+		if err != nil {
+			var se *skipError
+			if errors.As(err, &se) {
+				add(se.path, se.code, se.err.Error())
+			} else {
+				add(filepath.Join(dir, p.base), "error", err.Error())
+			}
+			continue
+		}
+		meta := p.nfo
+		if meta == "" {
+			meta = p.dms
+		}
+		switch {
+		case v.ThumbURL == "":
+			add(meta, CodeMissingThumb, "no <thumb> in metadata")
+		case !isAbsURL(v.ThumbURL) && !isFile(filepath.Join(dir, filepath.FromSlash(v.ThumbURL))):
+			add(meta, CodeMissingThumb, "thumb file not found: "+v.ThumbURL)
+		}
+	}
+	for _, bases := range folded {
+		if len(bases) < 2 {
+			continue
+		}
+		sort.Strings(bases)
+		for _, b := range bases {
+			add(filepath.Join(dir, b), CodeDuplicate, "base name differs only in case from "+strings.Join(others(bases, b), ", ")+"; files will not pair")
+		}
+	}
+}
+
+func isFolderNFO(path string) bool {
+	name := strings.ToLower(filepath.Base(path))
+	for _, n := range folderNFONames {
+		if name == n {
+			return true
+		}
+	}
+	return false
+}
+
+func isAbsURL(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && u.Scheme != ""
+}
+
+func others(all []string, except string) []string {
+	var out []string
+	for _, s := range all {
+		if s != except {
+			out = append(out, s)
+		}
+	}
+	return out
+}
@@ -0,0 +1,86 @@
+package browse
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestLint_ReportsSkippedItems(t *testing.T) {
+	root := t.TempDir()
+	write(t, filepath.Join(root, "a", "ok.strm"), "plugin://plugin.video.youtube/play/?video_id=abc")
+	write(t, filepath.Join(root, "a", "ok.nfo"), "<movie><title>OK</title><thumb>https://i.ytimg.com/x.jpg</thumb></movie>")
+	write(t, filepath.Join(root, "a", "orphan.nfo"), "<movie><title>Orphan</title></movie>")
+	write(t, filepath.Join(root, "a", "plugin.strm"), "plugin://plugin.video.unknown/?id=1")
+	write(t, filepath.Join(root, "a", "plugin.nfo"), "<movie><thumb>https://i.ytimg.com/x.jpg</thumb></movie>")
+	write(t, filepath.Join(root, "a", "noid.strm"), "plugin://plugin.video.youtube/play/")
+	write(t, filepath.Join(root, "a", "noid.nfo"), "<movie/>")
+	write(t, filepath.Join(root, "a", "badxml.strm"), "plugin://plugin.video.youtube/play/?video_id=x")
+	write(t, filepath.Join(root, "a", "badxml.nfo"), "<movie><title>unclosed")
+	write(t, filepath.Join(root, "a", "nothumb.strm"), "plugin://plugin.video.youtube/play/?video_id=y")
+	write(t, filepath.Join(root, "a", "nothumb.nfo"), "<movie><thumb>missing.jpg</thumb></movie>")
+	write(t, filepath.Join(root, "a", "dup.url"), "https://youtu.be/d1")
+	write(t, filepath.Join(root, "a", "dup.webloc"), "<plist><dict><key>URL</key><string>https://youtu.be/d2</string></dict></plist>")
+	write(t, filepath.Join(root, "a", "dup.nfo"), "<movie><thumb>https://i.ytimg.com/x.jpg</thumb></movie>")
+	write(t, filepath.Join(root, "a", "Case.strm"), "plugin://plugin.video.youtube/play/?video_id=z")
+	write(t, filepath.Join(root, "a", "case.nfo"), "<movie/>")
+	write(t, filepath.Join(root, "a", "tvshow.nfo"), "<tvshow><title>Show</title></tvshow>")
+
+	diags, err := Lint(root, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]string{}
+	for _, d := range diags {
+		got[d.Path+" "+d.Code] = d.Reason
+	}
+	for _, want := range []string{
+		"a/orphan.nfo " + CodeOrphanNFO,
+		"a/plugin.strm " + CodeUnsupported,
+		"a/noid.strm " + CodeEmptyID,
+		"a/badxml.nfo " + CodeBadNFO,
+		"a/nothumb.nfo " + CodeMissingThumb,
+		"a/dup.webloc " + CodeDuplicate,
+		"a/Case " + CodeDuplicate,
+		"a/Case.strm " + CodeNoMetadata,
+		"a/case.nfo " + CodeOrphanNFO,
+	} {
+		if _, ok := got[want]; !ok {
+			t.Errorf("missing diagnostic %q; got %v", want, got)
+		}
+	}
+	for k := range got {
+		if k == "a/ok.nfo "+CodeMissingThumb || k == "a/tvshow.nfo "+CodeOrphanNFO {
+			t.Errorf("unexpected diagnostic %q", k)
+		}
+	}
+}
+
+func TestLint_FolderConfigAndIgnore(t *testing.T) {
+	root := t.TempDir()
+	write(t, filepath.Join(root, "a", DirConfigName), `{"sort": "sideways"}`)
+	write(t, filepath.Join(root, "b", DirConfigName), `{"title": `)
+	write(t, filepath.Join(root, "junk", "orphan.nfo"), "<movie/>")
+	write(t, filepath.Join(root, "skip.nfo"), "<movie/>")
+	write(t, filepath.Join(root, IgnoreFileName), "junk/\nskip.*\n")
+
+	diags, err := Lint(root, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]bool{}
+	for _, d := range diags {
+		got[d.Path+" "+d.Code] = true
+	}
+	want := map[string]bool{
+		"a/" + DirConfigName + " " + CodeBadConfig: true,
+		"b/" + DirConfigName + " " + CodeBadConfig: true,
+	}
+	if len(got) != len(want) {
+		t.Fatalf("diagnostics = %v, want %v", got, want)
+	}
+	for k := range want {
+		if !got[k] {
+			t.Errorf("missing diagnostic %q; got %v", k, got)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package browse
 
 import (
+	"log/slog"
 	"os"
 	"path/filepath"
 	"sort"
@@ -9,11 +10,18 @@ import (
 
 	"github.com/claes/ytplv/internal/model"
 	"github.com/claes/ytplv/internal/parser"
+	"github.com/claes/ytplv/internal/source"
 )
 
 // BuildListing scans a directory under root and returns directories and paired videos.
 // rel must be a clean, relative path ("" or "." means root).
 func BuildListing(root, rel string) (model.Listing, error) {
+	return BuildListingCached(root, rel, nil)
+}
+
+// BuildListingCached is BuildListing reusing items parsed by earlier scans
+// from cache when their files are unchanged. A nil cache parses everything.
+func BuildListingCached(root, rel string, cache *ItemCache) (model.Listing, error) {
 	listing := model.Listing{Path: cleanRel(rel)}
 	if listing.Path != "" {
 		listing.ParentPath = parentOf(listing.Path)
@@ -28,100 +36,47 @@ func BuildListing(root, rel string) (model.Listing, error) {
 		return listing, err
 	}
 
-    // Collect .strm/.url base names and their paths; only include if matching .nfo exists.
-    type pair struct {
-        base  string
-        strm  string
-        url   string
-        nfo   string
-        mtime time.Time
-    }
-	pairs := make(map[string]*pair)
-
+	entries = loadIgnorer(root, listing.Path).filter(filepath.ToSlash(listing.Path), entries)
+	configs := make(map[string]DirConfig)
 	for _, e := range entries {
-		name := e.Name()
-		if e.IsDir() {
-			listing.Dirs = append(listing.Dirs, name)
+		if !e.IsDir() {
 			continue
 		}
-		ext := strings.ToLower(filepath.Ext(name))
-		base := strings.TrimSuffix(name, ext)
-        switch ext {
-        case ".strm":
-            p := pairs[base]
-            if p == nil {
-                p = &pair{base: base}
-                pairs[base] = p
-            }
-            p.strm = filepath.Join(dir, name)
-            if fi, err := os.Stat(filepath.Join(dir, name)); err == nil {
-                // only set mtime from .strm if no .url has been seen
-                if p.url == "" {
-                    p.mtime = fi.ModTime()
-                }
-            }
-        case ".url":
-            p := pairs[base]
-            if p == nil {
-                p = &pair{base: base}
-                pairs[base] = p
-            }
-            p.url = filepath.Join(dir, name)
-            if fi, err := os.Stat(filepath.Join(dir, name)); err == nil {
-                // .url takes precedence
-                p.mtime = fi.ModTime()
-            }
-        case ".nfo":
-            p := pairs[base]
-            if p == nil {
-                p = &pair{base: base}
-                pairs[base] = p
-            }
-            p.nfo = filepath.Join(dir, name)
-        }
-    }
-
-    for _, p := range pairs {
-        // Require metadata, and at least one of .url or .strm
-        if p.nfo == "" || (p.strm == "" && p.url == "") {
-            continue // only include pairs
-        }
-        var typ, vid, rawURL string
-        if p.url != "" {
-            if u, err := parser.ParseURLFile(p.url); err == nil {
-                rawURL = u
-            } else {
-                continue
-            }
-        } else {
-            t, v, err := parser.ParseStream(p.strm)
-            if err != nil || v == "" {
-                continue
-            }
-            typ, vid = t, v
-        }
-        title, plot, thumb, tags, err := parser.ParseNFO(p.nfo)
-        if err != nil {
-            continue
-        }
-        listing.Videos = append(listing.Videos, model.Video{
-            Name:     p.base,
-            Type:     typ,
-            VideoID:  vid,
-            URL:      rawURL,
-            Title:    title,
-            Plot:     plot,
-            ThumbURL: thumb,
-            Tags:     tags,
-        })
+		c := loadDirConfig(filepath.Join(dir, e.Name()))
+		if !c.Hidden {
+			listing.Dirs = append(listing.Dirs, e.Name())
+			configs[e.Name()] = c
+		}
+	}
+	pairs := collectPairs(dir, entries)
+	cache.retain(dir, pairs)
+	// Parse items concurrently into fixed slots, then append them in base
+	// name order so the result is the same however the workers ran.
+	items := sortedPairs(pairs)
+	videos := make([]model.Video, len(items))
+	added := make([]time.Time, len(items))
+	errs := make([]error, len(items))
+	forEach(len(items), func(i int) {
+		items[i].stat()
+		videos[i], added[i], errs[i] = cache.video(dir, items[i])
+	})
+	for i, p := range items {
+		if errs[i] != nil {
+			slog.Debug("listing skipped item", "err", errs[i])
+			continue // only include pairs
+		}
+		v := videos[i]
+		listing.Videos = append(listing.Videos, v)
 		// add to combined entries with mod time
-        listing.Entries = append(listing.Entries, model.Entry{
-            Kind:    "video",
-            Name:    titleOr(p.base, title),
-            ModTime: p.mtime,
-            Video:   &model.Video{Name: p.base, Type: typ, VideoID: vid, URL: rawURL, Title: title, Plot: plot, ThumbURL: thumb, Tags: tags},
-        })
-    }
+		ev := v
+		listing.Entries = append(listing.Entries, model.Entry{
+			Kind:    "video",
+			Name:    titleOr(p.base, v.Title),
+			ModTime: itemDate(&v, p.mtime),
+			Added:   added[i],
+			Video:   &ev,
+		})
+	}
 
 	sort.Strings(listing.Dirs)
 	sort.Slice(listing.Videos, func(i, j int) bool {
@@ -139,74 +94,20 @@ func BuildListing(root, rel string) (model.Listing, error) {
 		}
 		return strings.ToLower(ti) < strings.ToLower(tj)
 	})
-	// For each immediate subdirectory, compute newest .strm mtime among valid pairs to sort
-	for _, d := range listing.Dirs {
-		sub := filepath.Join(dir, d)
-		var latest time.Time
-        if des, err := os.ReadDir(sub); err == nil {
-            // build local map to require pairs within subdir
-            mp := map[string]struct {
-                hasMedia bool // .url or .strm
-                hasNfo  bool
-                m       time.Time
-            }{}
-            for _, de := range des {
-                if de.IsDir() {
-                    continue
-                }
-                ext := strings.ToLower(filepath.Ext(de.Name()))
-                base := strings.TrimSuffix(de.Name(), ext)
-                switch ext {
-                case ".strm":
-                    fi, _ := os.Stat(filepath.Join(sub, de.Name()))
-                    m := time.Time{}
-                    if fi != nil {
-                        m = fi.ModTime()
-                    }
-                    v := mp[base]
-                    v.hasMedia = true
-                    if v.m.IsZero() { // don't override .url mtime if already set
-                        v.m = m
-                    }
-                    mp[base] = v
-                case ".url":
-                    fi, _ := os.Stat(filepath.Join(sub, de.Name()))
-                    m := time.Time{}
-                    if fi != nil {
-                        m = fi.ModTime()
-                    }
-                    v := mp[base]
-                    // .url takes precedence for mtime as well
-                    v.hasMedia = true
-                    v.m = m
-                    mp[base] = v
-                case ".nfo":
-                    v := mp[base]
-                    v.hasNfo = true
-                    mp[base] = v
-                }
-            }
-            for _, v := range mp {
-                if v.hasMedia && v.hasNfo {
-                    if v.m.After(latest) {
-                        latest = v.m
-                    }
-                }
-            }
-        }
-		// Fallback: if no valid pairs were found, use directory's own mtime
-		if latest.IsZero() {
-			if fi, err := os.Stat(sub); err == nil {
-				latest = fi.ModTime()
-			}
-		}
-		listing.Entries = append(listing.Entries, model.Entry{
+	orderEpisodes(listing.Videos, func(v *model.Video) *model.Video { return v })
+	// For each immediate subdirectory, compute the newest item date to sort by
+	folders := make([]model.Entry, len(listing.Dirs))
+	forEach(len(listing.Dirs), func(i int) {
+		d := listing.Dirs[i]
+		folders[i] = model.Entry{
 			Kind:    "dir",
 			Name:    d,
 			Path:    cleanRel(filepath.Join(listing.Path, d)),
-			ModTime: latest,
-		})
-	}
+			ModTime: latestItemDate(root, filepath.Join(listing.Path, d), cache),
+			Folder:  folderMeta(dir, d, configs[d]),
+		}
+	})
+	listing.Entries = append(listing.Entries, folders...)
 	// Sort combined entries by modtime desc; if equal then by name
 	sort.SliceStable(listing.Entries, func(i, j int) bool {
 		mi, mj := listing.Entries[i].ModTime, listing.Entries[j].ModTime
@@ -215,9 +116,95 @@ func BuildListing(root, rel string) (model.Listing, error) {
 		}
 		return mi.After(mj)
 	})
+	orderEpisodes(listing.Entries, func(e *model.Entry) *model.Video { return e.Video })
 	return listing, nil
 }
 
+// latestItemDate returns the newest item date (see itemDate) among the
+// items directly in the folder rel, or the folder's own mtime when it holds
+// none. Items are parsed through cache, which keeps repeated parent listings
+// cheap.
+func latestItemDate(root, rel string, cache *ItemCache) time.Time {
+	dir := filepath.Join(root, rel)
+	var latest time.Time
+	if des, err := os.ReadDir(dir); err == nil {
+		des = loadIgnorer(root, rel).filter(filepath.ToSlash(rel), des)
+		for _, p := range collectPairs(dir, des) {
+			if !p.complete() {
+				continue
+			}
+			p.stat()
+			v, _, err := cache.video(dir, p)
+			if err != nil {
+				continue
+			}
+			if t := itemDate(&v, p.mtime); t.After(latest) {
+				latest = t
+			}
+		}
+	}
+	// Fallback: if no valid pairs were found, use directory's own mtime
+	if latest.IsZero() {
+		if fi, err := os.Stat(dir); err == nil {
+			latest = fi.ModTime()
+		}
+	}
+	return latest
+}
+
+// orderEpisodes reorders the episode items of s by show, season and episode
+// number. Episodes keep the slots they were sorted into, so movies, folders
+// and other items are not moved.
+func orderEpisodes[T any](s []T, video func(*T) *model.Video) {
+	var slots []int
+	var eps []T
+	for i := range s {
+		if v := video(&s[i]); v != nil && v.Kind == parser.KindEpisode {
+			slots = append(slots, i)
+			eps = append(eps, s[i])
+		}
+	}
+	if len(eps) < 2 {
+		return
+	}
+	sort.SliceStable(eps, func(i, j int) bool {
+		a, b := video(&eps[i]), video(&eps[j])
+		if a.ShowTitle != b.ShowTitle {
+			return strings.ToLower(a.ShowTitle) < strings.ToLower(b.ShowTitle)
+		}
+		if a.Season != b.Season {
+			return a.Season < b.Season
+		}
+		if a.Episode != b.Episode {
+			return a.Episode < b.Episode
+		}
+		return a.Name < b.Name
+	})
+	for k, i := range slots {
+		s[i] = eps[k]
+	}
+}
+
+// playlistIDs merges the ids inlined in a playlist id with those listed in
+// the optional .playlist sidecar, dropping duplicates.
+func playlistIDs(id, sidecar string) []string {
+	ids := source.InlineVideoIDs(id)
+	if sidecar != "" {
+		if more, err := parser.ParsePlaylist(sidecar); err == nil {
+			ids = append(ids, more...)
+		}
+	}
+	seen := make(map[string]bool, len(ids))
+	out := ids[:0]
+	for _, v := range ids {
+		if !seen[v] {
+			seen[v] = true
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
 func titleOr(name, title string) string {
 	if strings.TrimSpace(title) != "" {
 		return title
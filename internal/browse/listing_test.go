@@ -1,12 +1,17 @@
 package browse
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/claes/ytplv/internal/model"
 )
 
-func write(t *testing.T, path, content string) {
+func write(t testing.TB, path, content string) {
 	t.Helper()
 	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
 		t.Fatal(err)
@@ -35,6 +40,9 @@ func TestBuildListing_PairsOnly(t *testing.T) {
 	if l.Videos[0].VideoID != "abc123" {
 		t.Fatalf("bad video id: %q", l.Videos[0].VideoID)
 	}
+	if l.Videos[0].STRM != "plugin://plugin.video.youtube/play/?video_id=abc123" {
+		t.Fatalf("bad strm line: %q", l.Videos[0].STRM)
+	}
 }
 
 func TestBuildListing_URLPrecedence(t *testing.T) {
@@ -56,3 +64,178 @@ func TestBuildListing_URLPrecedence(t *testing.T) {
         t.Fatalf("expected URL override, got %q", l.Videos[0].URL)
     }
 }
+
+func TestBuildListing_EpisodeOrder(t *testing.T) {
+	root := t.TempDir()
+	ep := func(name string, season, episode int) {
+		write(t, filepath.Join(root, "s", name+".strm"), "plugin://plugin.video.svtplay/?id=/video/"+name)
+		write(t, filepath.Join(root, "s", name+".nfo"), fmt.Sprintf(
+			"<episodedetails><title>%s</title><showtitle>Show</showtitle><season>%d</season><episode>%d</episode></episodedetails>",
+			name, season, episode))
+	}
+	// written so that mtime and title order both disagree with episode order
+	ep("c", 2, 1)
+	ep("a", 1, 2)
+	ep("b", 1, 1)
+
+	l, err := BuildListing(root, "s")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, e := range l.Entries {
+		got = append(got, e.Video.Name)
+	}
+	if strings.Join(got, ",") != "b,a,c" {
+		t.Fatalf("expected episode order b,a,c; got %v", got)
+	}
+	if l.Videos[0].Name != "b" || l.Videos[2].Name != "c" {
+		t.Fatalf("expected Videos in episode order, got %v", l.Videos)
+	}
+}
+
+func TestBuildListing_FolderMetadata(t *testing.T) {
+	root := t.TempDir()
+	write(t, filepath.Join(root, "Posy", "tvshow.nfo"), "<tvshow><title>Posy Channel</title><plot>About</plot></tvshow>")
+	write(t, filepath.Join(root, "Posy", "poster.jpg"), "x")
+	write(t, filepath.Join(root, "Bare", "fanart.jpg"), "x")
+	if err := os.MkdirAll(filepath.Join(root, "Empty"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	l, err := BuildListing(root, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	byName := map[string]*model.Folder{}
+	for _, e := range l.Entries {
+		byName[e.Name] = e.Folder
+	}
+	if f := byName["Posy"]; f == nil || f.Title != "Posy Channel" || f.Plot != "About" || f.ThumbURL != "Posy/poster.jpg" {
+		t.Fatalf("bad Posy folder metadata: %+v", f)
+	}
+	if f := byName["Bare"]; f == nil || f.ThumbURL != "Bare/fanart.jpg" || f.Fanart != "Bare/fanart.jpg" {
+		t.Fatalf("bad Bare folder metadata: %+v", f)
+	}
+	if f := byName["Empty"]; f != nil {
+		t.Fatalf("expected no metadata for Empty, got %+v", f)
+	}
+}
+
+func TestBuildListing_DMSSidecar(t *testing.T) {
+	root := t.TempDir()
+	// sidecar only: title and source both come from .dms.json
+	write(t, filepath.Join(root, "d", "solo.dms.json"), `{"Title":"Solo","Resources":[{"MimeType":"video/mp4","Command":"play-stream abc123"}]}`)
+	// nfo + sidecar: NFO title wins, command supplies the source
+	write(t, filepath.Join(root, "d", "both.dms.json"), `{"Title":"Sidecar","Resources":[{"MimeType":"video/mp4","Command":"play-stream def456"}]}`)
+	write(t, filepath.Join(root, "d", "both.nfo"), "<movie><title>From NFO</title></movie>")
+
+	l, err := BuildListing(root, "d")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]model.Video{}
+	for _, v := range l.Videos {
+		got[v.Name] = v
+	}
+	if v := got["solo"]; v.Title != "Solo" || v.Type != "youtube" || v.VideoID != "abc123" {
+		t.Fatalf("bad sidecar-only video: %+v", v)
+	}
+	if v := got["both"]; v.Title != "From NFO" || v.VideoID != "def456" {
+		t.Fatalf("bad nfo+sidecar video: %+v", v)
+	}
+}
+
+func TestBuildListing_PlaylistSTRM(t *testing.T) {
+	root := t.TempDir()
+	write(t, filepath.Join(root, "p", "list.strm"), "plugin://plugin.video.youtube/play/?playlist_id=PLabc")
+	write(t, filepath.Join(root, "p", "list.nfo"), "<movie><title>List</title></movie>")
+	write(t, filepath.Join(root, "p", "list.playlist"), "v1\nv2\nv1\n")
+	write(t, filepath.Join(root, "p", "inline.strm"), "plugin://plugin.video.youtube/play/?video_ids=a,b,c")
+	write(t, filepath.Join(root, "p", "inline.nfo"), "<movie><title>Inline</title></movie>")
+
+	l, err := BuildListing(root, "p")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]model.Video{}
+	for _, v := range l.Videos {
+		got[v.Name] = v
+	}
+	if v := got["list"]; v.Type != "youtube-playlist" || v.VideoID != "PLabc" || strings.Join(v.VideoIDs, ",") != "v1,v2" {
+		t.Fatalf("bad playlist video: %+v", v)
+	}
+	if v := got["inline"]; strings.Join(v.VideoIDs, ",") != "a,b,c" {
+		t.Fatalf("bad inline playlist: %+v", v)
+	}
+}
+
+func TestBuildListing_LinkFormats(t *testing.T) {
+	root := t.TempDir()
+	write(t, filepath.Join(root, "l", "win.url"), "[InternetShortcut]\r\nURL=https://youtu.be/win\r\n")
+	write(t, filepath.Join(root, "l", "win.nfo"), "<movie><title>Win</title></movie>")
+	write(t, filepath.Join(root, "l", "mac.webloc"), `<plist><dict><key>URL</key><string>https://youtu.be/mac</string></dict></plist>`)
+	write(t, filepath.Join(root, "l", "mac.nfo"), "<movie><title>Mac</title></movie>")
+	write(t, filepath.Join(root, "l", "lin.desktop"), "[Desktop Entry]\nType=Link\nURL=https://youtu.be/lin\n")
+	write(t, filepath.Join(root, "l", "lin.nfo"), "<movie><title>Lin</title></movie>")
+
+	l, err := BuildListing(root, "l")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]string{}
+	for _, v := range l.Videos {
+		got[v.Name] = v.URL
+	}
+	want := map[string]string{"win": "https://youtu.be/win", "mac": "https://youtu.be/mac", "lin": "https://youtu.be/lin"}
+	for k, u := range want {
+		if got[k] != u {
+			t.Fatalf("%s: got %q, want %q (all: %v)", k, got[k], u, got)
+		}
+	}
+}
+
+func TestBuildListing_MetadataDates(t *testing.T) {
+	root := t.TempDir()
+	item := func(dir, name, nfo string) {
+		write(t, filepath.Join(root, dir, name+".strm"), "plugin://plugin.video.youtube/play/?video_id="+name)
+		write(t, filepath.Join(root, dir, name+".nfo"), nfo)
+	}
+	item("", "premiered", "<movie><title>P</title><premiered>2020-05-01</premiered></movie>")
+	item("", "aired", "<episodedetails><title>A</title><aired>2021-05-01</aired></episodedetails>")
+	item("", "sorttitle", "<movie><title>S</title><sorttitle>2025-06-11T14:23:20+00:00 Strange Filters</sorttitle></movie>")
+	item("", "mtime", "<movie><title>M</title><sorttitle>Not a date</sorttitle></movie>")
+	item("sub", "old", "<movie><title>O</title><premiered>2019-01-01</premiered></movie>")
+	// rewrite times as a sync job would; metadata dates must win
+	now := time.Now()
+	for _, name := range []string{"premiered", "aired", "sorttitle", "mtime"} {
+		if err := os.Chtimes(filepath.Join(root, name+".strm"), now, now); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	l, err := BuildListing(root, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]string{}
+	var order []string
+	for _, e := range l.Entries {
+		got[e.Name] = e.ModTime.UTC().Format(time.RFC3339)
+		order = append(order, e.Name)
+	}
+	want := map[string]string{
+		"P":   "2020-05-01T00:00:00Z",
+		"A":   "2021-05-01T00:00:00Z",
+		"S":   "2025-06-11T14:23:20Z",
+		"sub": "2019-01-01T00:00:00Z",
+	}
+	for name, w := range want {
+		if got[name] != w {
+			t.Errorf("%s: got %s, want %s", name, got[name], w)
+		}
+	}
+	if strings.Join(order, ",") != "M,S,A,P,sub" {
+		t.Fatalf("unexpected order %v", order)
+	}
+}
@@ -0,0 +1,247 @@
+package browse
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/claes/ytplv/internal/model"
+	"github.com/claes/ytplv/internal/parser"
+	"github.com/claes/ytplv/internal/source"
+)
+
+// pair groups the files of one item that share a base name: a media file
+// (.strm or a link file), metadata (.nfo or .dms.json) and sidecars.
+type pair struct {
+	base    string
+	strm    string
+	url     string // .url, .webloc or .desktop
+	nfo     string
+	dms     string
+	plist   string // .playlist sidecar
+	mtime   time.Time
+	fp      string   // file fingerprint, set by stat
+	shadows []string // further link files for the same base, ignored
+}
+
+// Skip reasons reported by pair.video, used as Diagnostic codes.
+const (
+	CodeNoMetadata  = "missing-nfo"      // media without .nfo or .dms.json
+	CodeOrphanNFO   = "orphan-nfo"       // .nfo without media
+	CodeOrphan      = "orphan-sidecar"   // .playlist without media
+	CodeUnsupported = "unsupported-strm" // .strm not matching any source
+	CodeEmptyID     = "empty-video-id"   // .strm matched but carries no id
+	CodeBadNFO      = "bad-nfo"          // .nfo failed to parse
+	CodeBadLink     = "bad-link"         // link file failed to parse or is empty
+	CodeBadDMS      = "bad-dms"          // .dms.json failed to parse or has no playable command
+	CodeBadSTRM     = "bad-strm"         // .strm could not be read
+)
+
+// skipError explains why a pair did not produce a video.
+type skipError struct {
+	code string
+	path string
+	err  error
+}
+
+func (e *skipError) Error() string { return fmt.Sprintf("%s: %s: %v", e.path, e.code, e.err) }
+
+func skip(code, path string, format string, args ...any) error {
+	return &skipError{code: code, path: path, err: fmt.Errorf(format, args...)}
+}
+
+// splitName returns the base name and the lower-cased kind of a file name.
+// Link extensions report parser.URLExt, since they pair like .url files.
+func splitName(name string) (base, kind string) {
+	ext := filepath.Ext(name)
+	if strings.HasSuffix(strings.ToLower(name), parser.DMSExt) {
+		ext = name[len(name)-len(parser.DMSExt):]
+	}
+	base = strings.TrimSuffix(name, ext)
+	kind = strings.ToLower(ext)
+	if parser.IsLinkExt(kind) {
+		kind = parser.URLExt
+	}
+	return base, kind
+}
+
+// collectPairs groups the files of dir by base name. Directories are skipped.
+// It does not touch the files; call stat for their mtimes.
+func collectPairs(dir string, entries []os.DirEntry) map[string]*pair {
+	pairs := make(map[string]*pair)
+	get := func(base string) *pair {
+		p := pairs[base]
+		if p == nil {
+			p = &pair{base: base}
+			pairs[base] = p
+		}
+		return p
+	}
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		name := e.Name()
+		base, kind := splitName(name)
+		full := filepath.Join(dir, name)
+		switch kind {
+		case ".strm":
+			get(base).strm = full
+		case parser.URLExt:
+			p := get(base)
+			if p.url != "" {
+				p.shadows = append(p.shadows, full)
+				continue
+			}
+			p.url = full
+		case ".nfo":
+			get(base).nfo = full
+		case parser.PlaylistExt:
+			get(base).plist = full
+		case parser.DMSExt:
+			get(base).dms = full
+		}
+	}
+	return pairs
+}
+
+// sortedPairs returns the pairs ordered by base name, so that scans process
+// (and ties in later sorts resolve) the same way every time.
+func sortedPairs(pairs map[string]*pair) []*pair {
+	out := make([]*pair, 0, len(pairs))
+	for _, p := range pairs {
+		out = append(out, p)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].base < out[j].base })
+	return out
+}
+
+// mtimeSource returns the file whose mtime dates the item: the link file
+// takes precedence over the .strm, and the .dms.json is a fallback.
+func (p *pair) mtimeSource() string {
+	switch {
+	case p.url != "":
+		return p.url
+	case p.strm != "":
+		return p.strm
+	default:
+		return p.dms
+	}
+}
+
+// stat stats every file of the pair, recording the item mtime and the
+// fingerprint used by ItemCache.
+func (p *pair) stat() {
+	src := p.mtimeSource()
+	var b strings.Builder
+	for _, f := range []string{p.strm, p.url, p.nfo, p.dms, p.plist} {
+		if f == "" {
+			continue
+		}
+		b.WriteString(f)
+		if fi, err := os.Stat(f); err == nil {
+			if f == src {
+				p.mtime = fi.ModTime()
+			}
+			b.WriteByte('|')
+			b.WriteString(strconv.FormatInt(fi.ModTime().UnixNano(), 10))
+			b.WriteByte('|')
+			b.WriteString(strconv.FormatInt(fi.Size(), 10))
+		}
+		b.WriteByte(';')
+	}
+	p.fp = b.String()
+}
+
+// complete reports whether p has both metadata and something to play,
+// without parsing any file.
+func (p *pair) complete() bool {
+	return (p.nfo != "" || p.dms != "") && (p.strm != "" || p.url != "" || p.dms != "")
+}
+
+// video builds the item for p. The error is a *skipError explaining why the
+// pair is not listed.
+func (p *pair) video() (model.Video, error) {
+	// Require metadata, and at least one of .url, .strm or a .dms.json sidecar
+	if p.strm == "" && p.url == "" && p.dms == "" {
+		if p.nfo == "" {
+			return model.Video{}, skip(CodeOrphan, p.plist, "no .strm, link file or .dms.json next to it")
+		}
+		return model.Video{}, skip(CodeOrphanNFO, p.nfo, "no .strm, link file or .dms.json next to it")
+	}
+	if p.nfo == "" && p.dms == "" {
+		media := p.url
+		if media == "" {
+			media = p.strm
+		}
+		return model.Video{}, skip(CodeNoMetadata, media, "no .nfo or .dms.json next to it")
+	}
+	var dms parser.DMS
+	if p.dms != "" {
+		d, err := parser.ParseDMS(p.dms)
+		if err != nil && p.nfo == "" {
+			return model.Video{}, skip(CodeBadDMS, p.dms, "%v", err)
+		}
+		dms = d
+	}
+	var typ, vid, rawURL, strm string
+	var start int
+	switch {
+	case p.url != "":
+		u, err := parser.ParseLink(p.url)
+		if err != nil {
+			return model.Video{}, skip(CodeBadLink, p.url, "%v", err)
+		}
+		if u == "" {
+			return model.Video{}, skip(CodeBadLink, p.url, "no URL found")
+		}
+		rawURL = u
+		start = source.StartOffset(u)
+	case p.strm != "":
+		st, err := parser.ReadStream(p.strm)
+		if err != nil {
+			return model.Video{}, skip(CodeBadSTRM, p.strm, "%v", err)
+		}
+		if st.Type == "" {
+			return model.Video{}, skip(CodeUnsupported, p.strm, "no registered source matches %q", firstLine(p.strm))
+		}
+		if st.ID == "" {
+			return model.Video{}, skip(CodeEmptyID, p.strm, "%s stream without a video id", st.Type)
+		}
+		typ, vid, start, strm = st.Type, st.ID, st.Start, st.Line
+	default:
+		typ, vid, rawURL = dms.Stream()
+		if vid == "" && rawURL == "" {
+			return model.Video{}, skip(CodeBadDMS, p.dms, "no playable resource command")
+		}
+	}
+	v := model.Video{Name: p.base, Type: typ, VideoID: vid, URL: rawURL, STRM: strm, Start: start}
+	if typ == source.YouTubePlaylist.Name() {
+		v.VideoIDs = playlistIDs(vid, p.plist)
+	}
+	if p.nfo != "" {
+		md, err := parser.ParseNFO(p.nfo)
+		if err != nil {
+			return model.Video{}, skip(CodeBadNFO, p.nfo, "%v", err)
+		}
+		md.Apply(&v)
+	}
+	if v.Title == "" {
+		v.Title = dms.Title
+	}
+	return v, nil
+}
+
+// firstLine returns the first line of a small text file, trimmed, for messages.
+func firstLine(path string) string {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	line, _, _ := strings.Cut(string(b), "\n")
+	return strings.TrimSpace(line)
+}
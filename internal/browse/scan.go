@@ -0,0 +1,41 @@
+package browse
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// scanWorkers bounds the goroutines a single scan uses to stat and parse
+// items. Scanning is mostly waiting on the filesystem, so it allows more
+// workers than there are CPUs.
+var scanWorkers = min(4*runtime.GOMAXPROCS(0), 32)
+
+// forEach calls fn for every index in [0, n) on at most scanWorkers
+// goroutines and returns when all calls are done. Callers write results into
+// slot i so the output order does not depend on scheduling.
+func forEach(n int, fn func(i int)) {
+	workers := min(scanWorkers, n)
+	if workers <= 1 {
+		for i := 0; i < n; i++ {
+			fn(i)
+		}
+		return
+	}
+	var next atomic.Int64
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func() {
+			defer wg.Done()
+			for {
+				i := int(next.Add(1)) - 1
+				if i >= n {
+					return
+				}
+				fn(i)
+			}
+		}()
+	}
+	wg.Wait()
+}
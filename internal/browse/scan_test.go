@@ -0,0 +1,118 @@
+package browse
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// syntheticTree writes channels folders of perChannel paired items each,
+// plus a flat folder holding channels*perChannel items, with varying mtimes.
+func syntheticTree(tb testing.TB, root string, channels, perChannel int) {
+	tb.Helper()
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	item := func(dir string, n int) {
+		name := filepath.Join(root, dir, fmt.Sprintf("video %05d", n))
+		write(tb, name+".strm", fmt.Sprintf("plugin://plugin.video.youtube/play/?video_id=id%09d", n))
+		write(tb, name+".nfo", fmt.Sprintf("<movie><title>Video %d</title><plot>Plot %d</plot><tag>t%d</tag></movie>", n%97, n, n%13))
+		// Many items share an mtime so that tie-breaking is exercised.
+		mt := base.Add(time.Duration(n%50) * time.Hour)
+		if err := os.Chtimes(name+".strm", mt, mt); err != nil {
+			tb.Fatal(err)
+		}
+	}
+	for c := 0; c < channels; c++ {
+		for i := 0; i < perChannel; i++ {
+			n := c*perChannel + i
+			item(fmt.Sprintf("channel %02d", c), n)
+			item("flat", n)
+		}
+	}
+}
+
+func TestBuildListing_ParallelMatchesSerial(t *testing.T) {
+	root := t.TempDir()
+	syntheticTree(t, root, 4, 50)
+	write(t, filepath.Join(root, "flat", "broken.strm"), "not a stream")
+	write(t, filepath.Join(root, "flat", "broken.nfo"), "<movie/>")
+
+	for _, rel := range []string{"", "flat", "channel 01"} {
+		saved := scanWorkers
+		scanWorkers = 1
+		serial, err := BuildListing(root, rel)
+		scanWorkers = saved
+		if err != nil {
+			t.Fatal(err)
+		}
+		for run := 0; run < 3; run++ {
+			parallel, err := BuildListingCached(root, rel, NewItemCache())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(serial, parallel) {
+				t.Fatalf("%q: parallel listing differs from serial one", rel)
+			}
+		}
+	}
+}
+
+func TestForEach_VisitsEveryIndexOnce(t *testing.T) {
+	const n = 1000
+	seen := make([]int, n)
+	forEach(n, func(i int) { seen[i]++ })
+	for i, c := range seen {
+		if c != 1 {
+			t.Fatalf("index %d visited %d times", i, c)
+		}
+	}
+	forEach(0, func(int) { t.Fatal("called for empty range") })
+}
+
+// BenchmarkBuildListing scans a synthetic 10k-item tree: ten folders of 1000
+// items for the root listing, and one folder holding all 10k items.
+func BenchmarkBuildListing(b *testing.B) {
+	root := b.TempDir()
+	syntheticTree(b, root, 10, 1000)
+
+	for _, bc := range []struct {
+		name    string
+		rel     string
+		workers int
+	}{
+		{"root/serial", "", 1},
+		{"root/parallel", "", scanWorkers},
+		{"flat/serial", "flat", 1},
+		{"flat/parallel", "flat", scanWorkers},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			saved := scanWorkers
+			scanWorkers = bc.workers
+			defer func() { scanWorkers = saved }()
+			for i := 0; i < b.N; i++ {
+				if _, err := BuildListing(root, bc.rel); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkBuildListingCached measures a rescan of the 10k-item folder when
+// every item is already cached, the common case behind the library index.
+func BenchmarkBuildListingCached(b *testing.B) {
+	root := b.TempDir()
+	syntheticTree(b, root, 10, 1000)
+	cache := NewItemCache()
+	if _, err := BuildListingCached(root, "flat", cache); err != nil {
+		b.Fatal(err)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := BuildListingCached(root, "flat", cache); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,226 @@
+package browse
+
+import (
+	"math/rand"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/claes/ytplv/internal/model"
+)
+
+// SortOrder selects how listing entries are ordered.
+type SortOrder string
+
+const (
+	SortDate     SortOrder = "date"     // newest first
+	SortOldest   SortOrder = "oldest"   // date, oldest first
+	SortTitle    SortOrder = "title"    // display title, natural Swedish collation
+	SortName     SortOrder = "name"     // file or folder name, natural collation
+	SortDuration SortOrder = "duration" // shortest first, unknown runtimes last
+	SortRandom   SortOrder = "random"   // shuffled by a seed
+)
+
+// SortOrders lists the supported orders, default first.
+var SortOrders = []SortOrder{SortDate, SortOldest, SortTitle, SortName, SortDuration, SortRandom}
+
+// ParseSortOrder returns the order named s, if it is supported.
+func ParseSortOrder(s string) (SortOrder, bool) {
+	for _, o := range SortOrders {
+		if string(o) == s {
+			return o, true
+		}
+	}
+	return "", false
+}
+
+// SortEntries orders entries in place. With foldersFirst, folders precede
+// videos and each group is ordered on its own. seed only affects
+// SortRandom, and the same seed always gives the same order. Except in
+// random and duration order, episodes are kept in show/season/episode order.
+func SortEntries(entries []model.Entry, order SortOrder, foldersFirst bool, seed int64) {
+	less := entryLess(order)
+	if order == SortRandom {
+		// order by name first so the shuffle only depends on the seed
+		sort.SliceStable(entries, func(i, j int) bool { return entries[i].Name < entries[j].Name })
+		r := rand.New(rand.NewSource(seed))
+		r.Shuffle(len(entries), func(i, j int) { entries[i], entries[j] = entries[j], entries[i] })
+		less = func(a, b *model.Entry) bool { return false }
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		a, b := &entries[i], &entries[j]
+		if foldersFirst && (a.Kind == "dir") != (b.Kind == "dir") {
+			return a.Kind == "dir"
+		}
+		return less(a, b)
+	})
+	if order != SortRandom && order != SortDuration {
+		orderEpisodes(entries, func(e *model.Entry) *model.Video { return e.Video })
+	}
+}
+
+func entryLess(order SortOrder) func(a, b *model.Entry) bool {
+	switch order {
+	case SortTitle:
+		return func(a, b *model.Entry) bool {
+			if c := Collate(entryTitle(a), entryTitle(b)); c != 0 {
+				return c < 0
+			}
+			return Collate(entryName(a), entryName(b)) < 0
+		}
+	case SortName:
+		return func(a, b *model.Entry) bool {
+			return Collate(entryName(a), entryName(b)) < 0
+		}
+	case SortDuration:
+		return func(a, b *model.Entry) bool {
+			da, db := entryRuntime(a), entryRuntime(b)
+			if da != db {
+				if da == 0 || db == 0 {
+					return db == 0
+				}
+				return da < db
+			}
+			return Collate(entryTitle(a), entryTitle(b)) < 0
+		}
+	case SortOldest:
+		return func(a, b *model.Entry) bool {
+			da, db := a.ModTime, b.ModTime
+			if da.Equal(db) {
+				return strings.ToLower(a.Name) < strings.ToLower(b.Name)
+			}
+			return da.Before(db)
+		}
+	default:
+		return func(a, b *model.Entry) bool {
+			da, db := a.ModTime, b.ModTime
+			if da.Equal(db) {
+				return strings.ToLower(a.Name) < strings.ToLower(b.Name)
+			}
+			return da.After(db)
+		}
+	}
+}
+
+func entryTitle(e *model.Entry) string {
+	if e.Folder != nil && e.Folder.Title != "" {
+		return e.Folder.Title
+	}
+	if e.Video != nil && e.Video.Title != "" {
+		return e.Video.Title
+	}
+	return e.Name
+}
+
+func entryName(e *model.Entry) string {
+	if e.Video != nil {
+		return e.Video.Name
+	}
+	return e.Name
+}
+
+func entryRuntime(e *model.Entry) int {
+	if e.Video != nil {
+		return e.Video.Runtime
+	}
+	return 0
+}
+
+// Collate compares a and b for display order: case-insensitively, with
+// runs of digits compared by value ("Part 2" before "Part 10"), and letters
+// in Swedish alphabetical order, so å, ä and ö follow z while other accented
+// letters sort with their base letter.
+func Collate(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	i, j := 0, 0
+	for i < len(ra) && j < len(rb) {
+		if isDigit(ra[i]) && isDigit(rb[j]) {
+			ei, ej := digitRun(ra, i), digitRun(rb, j)
+			if c := compareNumbers(ra[i:ei], rb[j:ej]); c != 0 {
+				return c
+			}
+			i, j = ei, ej
+			continue
+		}
+		ka, kb := collationKey(ra[i]), collationKey(rb[j])
+		if ka != kb {
+			if ka < kb {
+				return -1
+			}
+			return 1
+		}
+		i++
+		j++
+	}
+	switch {
+	case len(ra)-i < len(rb)-j:
+		return -1
+	case len(ra)-i > len(rb)-j:
+		return 1
+	}
+	return strings.Compare(a, b)
+}
+
+func isDigit(r rune) bool { return r >= '0' && r <= '9' }
+
+func digitRun(r []rune, i int) int {
+	for i < len(r) && isDigit(r[i]) {
+		i++
+	}
+	return i
+}
+
+// compareNumbers compares two digit runs by value.
+func compareNumbers(a, b []rune) int {
+	for len(a) > 1 && a[0] == '0' {
+		a = a[1:]
+	}
+	for len(b) > 1 && b[0] == '0' {
+		b = b[1:]
+	}
+	if len(a) != len(b) {
+		if len(a) < len(b) {
+			return -1
+		}
+		return 1
+	}
+	return strings.Compare(string(a), string(b))
+}
+
+// swedishFold maps accented letters to the letter they sort as in Swedish.
+var swedishFold = map[rune]rune{
+	'à': 'a', 'á': 'a', 'â': 'a', 'ã': 'a',
+	'æ': 'ä', 'ø': 'ö',
+	'ç': 'c',
+	'è': 'e', 'é': 'e', 'ê': 'e', 'ë': 'e',
+	'ì': 'i', 'í': 'i', 'î': 'i', 'ï': 'i',
+	'ñ': 'n',
+	'ò': 'o', 'ó': 'o', 'ô': 'o', 'õ': 'o',
+	'ù': 'u', 'ú': 'u', 'û': 'u',
+	'ü': 'y', 'ý': 'y', 'ÿ': 'y',
+}
+
+// collationKey returns the sort weight of r: punctuation and spaces first,
+// then digits, a to z, å, ä, ö, and any other letter by code point.
+func collationKey(r rune) int {
+	r = unicode.ToLower(r)
+	if f, ok := swedishFold[r]; ok {
+		r = f
+	}
+	const class = 1 << 21 // above any code point
+	switch {
+	case r >= 'a' && r <= 'z':
+		return 2*class + int(r)
+	case r == 'å':
+		return 2*class + 'z' + 1
+	case r == 'ä':
+		return 2*class + 'z' + 2
+	case r == 'ö':
+		return 2*class + 'z' + 3
+	case unicode.IsLetter(r):
+		return 3*class + int(r)
+	case isDigit(r):
+		return class + int(r)
+	}
+	return int(r)
+}
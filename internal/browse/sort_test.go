@@ -0,0 +1,68 @@
+package browse
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/claes/ytplv/internal/model"
+)
+
+func TestCollate_NaturalSwedish(t *testing.T) {
+	in := []string{"Öland", "zebra", "Part 10", "Ängel", "Åre", "part 2", "Éclair", "apa", "Part 02b"}
+	sort.Slice(in, func(i, j int) bool { return Collate(in[i], in[j]) < 0 })
+	want := []string{"apa", "Éclair", "part 2", "Part 02b", "Part 10", "zebra", "Åre", "Ängel", "Öland"}
+	if !reflect.DeepEqual(in, want) {
+		t.Fatalf("got %q, want %q", in, want)
+	}
+}
+
+func entryNames(es []model.Entry) []string {
+	out := make([]string, len(es))
+	for i, e := range es {
+		out[i] = e.Name
+	}
+	return out
+}
+
+func TestSortEntries_Orders(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2025, 1, d, 0, 0, 0, 0, time.UTC) }
+	entries := func() []model.Entry {
+		return []model.Entry{
+			{Kind: "video", Name: "b", ModTime: day(1), Video: &model.Video{Name: "file2", Title: "Äpple", Runtime: 30}},
+			{Kind: "dir", Name: "folder", ModTime: day(3)},
+			{Kind: "video", Name: "a", ModTime: day(4), Video: &model.Video{Name: "file10", Title: "Zoo"}},
+			{Kind: "video", Name: "c", ModTime: day(2), Video: &model.Video{Name: "file1", Title: "apa", Runtime: 5}},
+		}
+	}
+	for _, tc := range []struct {
+		order        SortOrder
+		foldersFirst bool
+		want         []string
+	}{
+		{SortDate, false, []string{"a", "folder", "c", "b"}},
+		{SortDate, true, []string{"folder", "a", "c", "b"}},
+		{SortOldest, false, []string{"b", "c", "folder", "a"}},
+		{SortTitle, false, []string{"c", "folder", "a", "b"}},
+		{SortName, false, []string{"c", "b", "a", "folder"}},
+		{SortDuration, false, []string{"c", "b", "folder", "a"}},
+	} {
+		es := entries()
+		SortEntries(es, tc.order, tc.foldersFirst, 0)
+		if got := entryNames(es); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s (folders first %v): got %v, want %v", tc.order, tc.foldersFirst, got, tc.want)
+		}
+	}
+
+	a, b := entries(), entries()
+	b[0], b[3] = b[3], b[0]
+	SortEntries(a, SortRandom, true, 42)
+	SortEntries(b, SortRandom, true, 42)
+	if !reflect.DeepEqual(entryNames(a), entryNames(b)) {
+		t.Fatalf("random order should only depend on the seed: %v vs %v", entryNames(a), entryNames(b))
+	}
+	if a[0].Name != "folder" {
+		t.Fatalf("folders first should apply to random order: %v", entryNames(a))
+	}
+}
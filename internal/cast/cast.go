@@ -0,0 +1,126 @@
+// Package cast holds the playback backends that castweb hands items to, such
+// as the ytcast command or the SVT Play forwarding endpoint, and the router
+// choosing one for each request.
+package cast
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Capability is an operation a caster may implement.
+type Capability string
+
+const (
+	CapPlay  Capability = "play"  // start playing a URL
+	CapQueue Capability = "queue" // add a URL to the device's queue
+	CapPair  Capability = "pair"  // pair with a device using a TV code
+	CapList  Capability = "list"  // list the devices that can be cast to
+	CapStop  Capability = "stop"  // stop playback (Controller)
+	CapPause Capability = "pause" // toggle pause (Controller)
+)
+
+// Media is what is cast.
+type Media struct {
+	URL  string // canonical web URL, with any start offset applied
+	STRM string // the item's .strm line as written (often a plugin:// URL), if any
+}
+
+// Device is a cast target reported by ListDevices.
+type Device struct {
+	ID   string // value to pass as device to Play and Queue; empty if unknown
+	Name string // human-readable description
+}
+
+// String returns the device as one line of text.
+func (d Device) String() string {
+	switch {
+	case d.ID == "" || d.ID == d.Name:
+		return d.Name
+	case d.Name == "":
+		return d.ID
+	}
+	return d.ID + "\t" + d.Name
+}
+
+// Caster is a playback backend. Operations a caster does not list in
+// Capabilities return ErrNotSupported.
+type Caster interface {
+	// Name identifies the caster in configuration and logs.
+	Name() string
+	// Capabilities lists the operations the caster implements.
+	Capabilities() []Capability
+	// Play starts playing m on device.
+	Play(ctx context.Context, device string, m Media) error
+	// Queue adds m to the queue of device.
+	Queue(ctx context.Context, device string, m Media) error
+	// Pair pairs with a device showing code.
+	Pair(ctx context.Context, code string) error
+	// ListDevices returns the devices that can be cast to.
+	ListDevices(ctx context.Context) ([]Device, error)
+}
+
+// Controller is implemented by casters that can control playback once it
+// has started, and that list CapStop and CapPause.
+type Controller interface {
+	// Stop stops playback on device.
+	Stop(ctx context.Context, device string) error
+	// PlayPause pauses or resumes playback on device.
+	PlayPause(ctx context.Context, device string) error
+}
+
+// StateStore keeps small amounts of caster state, such as paired devices,
+// across restarts.
+type StateStore interface {
+	// LoadCasterState decodes the state saved for caster into v, leaving v
+	// alone when there is none.
+	LoadCasterState(caster string, v any) error
+	// SaveCasterState replaces the state of caster with v.
+	SaveCasterState(caster string, v any) error
+}
+
+// Stateful is implemented by casters that keep state in a StateStore.
+type Stateful interface {
+	SetStateStore(StateStore)
+}
+
+// Supports reports whether c offers capability cp.
+func Supports(c Caster, cp Capability) bool {
+	if c == nil {
+		return false
+	}
+	for _, x := range c.Capabilities() {
+		if x == cp {
+			return true
+		}
+	}
+	return false
+}
+
+// Errors describing a request a caster cannot serve. They are the caller's
+// fault, unlike an *Error.
+var (
+	ErrNotSupported   = errors.New("operation not supported")
+	ErrInvalidURL     = errors.New("invalid url")
+	ErrUnsupportedURL = errors.New("unsupported url")
+	ErrNoDevice       = errors.New("device not configured")
+	ErrInvalidCode    = errors.New("invalid pairing code")
+	ErrNotPlaying     = errors.New("nothing is playing") // from Controller methods
+)
+
+// Error is a failure of the backend itself while performing Op.
+type Error struct {
+	Caster string
+	Op     Capability
+	// Remote is set when a remote service failed, as opposed to a local
+	// command, so that HTTP callers can answer 502 rather than 500.
+	Remote bool
+	Err    error
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s %s: %v", e.Caster, e.Op, e.Err)
+}
+
+func (e *Error) Unwrap() error { return e.Err }
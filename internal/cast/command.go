@@ -0,0 +1,263 @@
+package cast
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+)
+
+func init() {
+	RegisterType("command", func(name string, spec json.RawMessage) (Caster, error) {
+		var c struct {
+			Name string `json:"name"`
+			Type string `json:"type"`
+			CommandConfig
+		}
+		if err := decodeSpec(spec, &c); err != nil {
+			return nil, err
+		}
+		return NewCommand(name, c.CommandConfig)
+	})
+}
+
+// CommandConfig describes a caster that runs a program per operation. Each
+// argv entry is a text/template executed with CommandData, so
+//
+//	["catt", "-d", "{{.Device}}", "cast", "{{.URL}}"]
+//
+// casts with catt. Arguments are passed as-is, without a shell. Operations
+// without an argv are not supported.
+type CommandConfig struct {
+	Play  []string `json:"play,omitempty"`
+	Queue []string `json:"queue,omitempty"`
+	Pair  []string `json:"pair,omitempty"`
+	List  []string `json:"list,omitempty"`
+
+	Timeout Duration          `json:"timeout,omitempty"` // per run; default DefaultTimeout
+	Env     map[string]string `json:"env,omitempty"`     // added to castweb's environment
+	Dir     string            `json:"dir,omitempty"`     // working directory; default castweb's
+
+	// Devices is a regular expression applied to each line the list
+	// command prints. Lines that do not match are skipped; the named
+	// groups "id" and "name" become the Device fields (the whole match
+	// when absent). Without it every non-empty line is a device name.
+	Devices string `json:"devices,omitempty"`
+	// Fail is a regular expression marking a run as failed when it
+	// matches stdout, for programs that exit 0 on errors.
+	Fail string `json:"fail,omitempty"`
+}
+
+// CommandData is what argv templates are executed with.
+type CommandData struct {
+	Device string // device to cast to; play and queue
+	URL    string // URL to cast; play and queue
+	STRM   string // the item's .strm line, if any; play and queue
+	Code   string // pairing code; pair
+}
+
+// Command is a caster running the programs of a CommandConfig.
+type Command struct {
+	name    string
+	argv    map[Capability][]*template.Template
+	Timeout time.Duration
+	Env     []string // "KEY=value" entries added to the environment
+	Dir     string
+	devices *regexp.Regexp
+	fail    *regexp.Regexp
+}
+
+// NewCommand returns a command caster, checking the templates and patterns
+// of cfg.
+func NewCommand(name string, cfg CommandConfig) (*Command, error) {
+	c := &Command{
+		name:    name,
+		argv:    make(map[Capability][]*template.Template),
+		Timeout: cfg.Timeout.or(DefaultTimeout),
+		Dir:     cfg.Dir,
+	}
+	for op, argv := range map[Capability][]string{CapPlay: cfg.Play, CapQueue: cfg.Queue, CapPair: cfg.Pair, CapList: cfg.List} {
+		if argv == nil {
+			continue
+		}
+		if len(argv) == 0 || argv[0] == "" {
+			return nil, fmt.Errorf("%s: empty command", op)
+		}
+		for i, arg := range argv {
+			t, err := template.New(fmt.Sprintf("%s[%d]", op, i)).Parse(arg)
+			if err == nil {
+				// catch unknown fields now rather than at the first cast
+				err = t.Execute(io.Discard, CommandData{})
+			}
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", op, err)
+			}
+			c.argv[op] = append(c.argv[op], t)
+		}
+	}
+	if len(c.argv) == 0 {
+		return nil, fmt.Errorf("no commands")
+	}
+	for k, v := range cfg.Env {
+		c.Env = append(c.Env, k+"="+v)
+	}
+	sort.Strings(c.Env)
+	var err error
+	if cfg.Devices != "" {
+		if c.devices, err = regexp.Compile(cfg.Devices); err != nil {
+			return nil, fmt.Errorf("devices: %w", err)
+		}
+	}
+	if cfg.Fail != "" {
+		if c.fail, err = regexp.Compile(cfg.Fail); err != nil {
+			return nil, fmt.Errorf("fail: %w", err)
+		}
+	}
+	return c, nil
+}
+
+func (c *Command) Name() string { return c.name }
+
+func (c *Command) Capabilities() []Capability {
+	var caps []Capability
+	for _, op := range []Capability{CapPlay, CapQueue, CapPair, CapList} {
+		if c.argv[op] != nil {
+			caps = append(caps, op)
+		}
+	}
+	return caps
+}
+
+func (c *Command) Play(ctx context.Context, device string, m Media) error {
+	_, err := c.run(ctx, CapPlay, CommandData{Device: device, URL: m.URL, STRM: m.STRM})
+	return err
+}
+
+func (c *Command) Queue(ctx context.Context, device string, m Media) error {
+	_, err := c.run(ctx, CapQueue, CommandData{Device: device, URL: m.URL, STRM: m.STRM})
+	return err
+}
+
+func (c *Command) Pair(ctx context.Context, code string) error {
+	_, err := c.run(ctx, CapPair, CommandData{Code: code})
+	return err
+}
+
+// ListDevices runs the list command and parses its output (see
+// CommandConfig.Devices).
+func (c *Command) ListDevices(ctx context.Context) ([]Device, error) {
+	out, err := c.run(ctx, CapList, CommandData{})
+	if err != nil {
+		return nil, err
+	}
+	return parseDevices(out, c.devices), nil
+}
+
+// parseDevices turns the lines of out into devices using re (see
+// CommandConfig.Devices).
+func parseDevices(out string, re *regexp.Regexp) []Device {
+	var devs []Device
+	for _, line := range strings.Split(out, "\n") {
+		line = strings.TrimRight(line, "\r")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if re == nil {
+			devs = append(devs, Device{Name: line})
+			continue
+		}
+		m := re.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		d := Device{ID: m[0], Name: m[0]}
+		if i := re.SubexpIndex("id"); i > 0 {
+			d.ID = m[i]
+		}
+		if i := re.SubexpIndex("name"); i > 0 {
+			d.Name = m[i]
+		}
+		devs = append(devs, d)
+	}
+	return devs
+}
+
+// args executes the argv templates of op with data. A template referring to
+// .Device fails with ErrNoDevice when there is none.
+func (c *Command) args(op Capability, data CommandData) ([]string, error) {
+	tpls := c.argv[op]
+	if tpls == nil {
+		return nil, ErrNotSupported
+	}
+	argv := make([]string, 0, len(tpls))
+	for _, t := range tpls {
+		if data.Device == "" && strings.Contains(t.Root.String(), ".Device") {
+			return nil, ErrNoDevice
+		}
+		var b strings.Builder
+		if err := t.Execute(&b, data); err != nil {
+			return nil, &Error{Caster: c.name, Op: op, Err: err}
+		}
+		argv = append(argv, b.String())
+	}
+	return argv, nil
+}
+
+// run executes the command of op and returns its stdout.
+func (c *Command) run(ctx context.Context, op Capability, data CommandData) (string, error) {
+	argv, err := c.args(op, data)
+	if err != nil {
+		return "", err
+	}
+	ctx, cancel := context.WithTimeout(ctx, c.Timeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, argv[0], argv[1:]...)
+	cmd.Dir = c.Dir
+	// don't wait for children that keep stdout open after a timeout
+	cmd.WaitDelay = time.Second
+	if len(c.Env) > 0 {
+		cmd.Env = append(os.Environ(), c.Env...)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	slog.Debug("command exec", "caster", c.name, "op", op, "prog", argv[0], "args", quoteArgs(argv[1:]))
+	err = cmd.Run()
+	out := stdout.String()
+	if err == nil && c.fail != nil {
+		if loc := c.fail.FindStringIndex(out); loc != nil {
+			err = fmt.Errorf("output matched fail pattern: %q", out[loc[0]:loc[1]])
+		}
+	}
+	if err != nil {
+		exitCode := 0
+		if ee, ok := err.(*exec.ExitError); ok && ee.ProcessState != nil {
+			exitCode = ee.ProcessState.ExitCode()
+		}
+		if ctx.Err() == context.DeadlineExceeded {
+			err = fmt.Errorf("timed out after %s", c.Timeout)
+		}
+		slog.Error("command failed", "caster", c.name, "op", op, "prog", argv[0], "err", err, "exit", exitCode,
+			"stdout", strings.TrimSpace(out), "stderr", strings.TrimSpace(stderr.String()))
+		return "", &Error{Caster: c.name, Op: op, Err: err}
+	}
+	return out, nil
+}
+
+// quoteArgs formats args for logging, quoting each one.
+func quoteArgs(args []string) string {
+	q := make([]string, 0, len(args))
+	for _, a := range args {
+		q = append(q, fmt.Sprintf("%q", a))
+	}
+	return strings.Join(q, " ")
+}
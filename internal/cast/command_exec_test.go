@@ -0,0 +1,75 @@
+//go:build integration
+
+package cast
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCommand_Run(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("test script is POSIX sh")
+	}
+	dir := t.TempDir()
+	script := filepath.Join(dir, "fake-cast")
+	body := "#!/bin/sh\n" +
+		"case \"$1\" in\n" +
+		"  play) printf '%s %s %s\\n' \"$2\" \"$CAST_TOKEN\" \"$(pwd)\" > trace ;;\n" +
+		"  queue) echo 'Error: no session' ;;\n" +
+		"  list) printf 'tv1 Living room\\ntv2 Kitchen\\n' ;;\n" +
+		"  pair) sleep 5 ;;\n" +
+		"esac\n"
+	if err := os.WriteFile(script, []byte(body), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	c, err := NewCommand("fake", CommandConfig{
+		Play:    []string{script, "play", "{{.URL}}"},
+		Queue:   []string{script, "queue", "{{.URL}}"},
+		List:    []string{script, "list"},
+		Pair:    []string{script, "pair", "{{.Code}}"},
+		Env:     map[string]string{"CAST_TOKEN": "secret"},
+		Dir:     dir,
+		Devices: `^(?P<id>\S+) (?P<name>.+)$`,
+		Fail:    `(?m)^Error: .*$`,
+		Timeout: Duration(200 * time.Millisecond),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	if err := c.Play(ctx, "", Media{URL: "https://example.com/v"}); err != nil {
+		t.Fatal(err)
+	}
+	trace, err := os.ReadFile(filepath.Join(dir, "trace"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := strings.TrimSpace(string(trace)), "https://example.com/v secret "+dir; got != want {
+		t.Fatalf("trace = %q, want %q", got, want)
+	}
+
+	var ce *Error
+	if err := c.Queue(ctx, "", Media{URL: "u"}); !errors.As(err, &ce) || !strings.Contains(err.Error(), "no session") {
+		t.Fatalf("expected fail pattern error, got %v", err)
+	}
+
+	devs, err := c.ListDevices(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(devs) != 2 || devs[1] != (Device{ID: "tv2", Name: "Kitchen"}) {
+		t.Fatalf("devices = %+v", devs)
+	}
+
+	if err := c.Pair(ctx, "1234"); !errors.As(err, &ce) || !strings.Contains(err.Error(), "timed out") {
+		t.Fatalf("expected timeout, got %v", err)
+	}
+}
@@ -0,0 +1,84 @@
+package cast
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+func TestCommand_Args(t *testing.T) {
+	c, err := NewCommand("catt", CommandConfig{
+		Play: []string{"catt", "-d", "{{.Device}}", "cast", "{{.URL}}"},
+		Pair: []string{"pair-tool", "--code={{.Code}}"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := c.args(CapPlay, CommandData{Device: "Living Room", URL: "https://youtu.be/x?a=1&b=2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"catt", "-d", "Living Room", "cast", "https://youtu.be/x?a=1&b=2"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("args = %q, want %q", got, want)
+	}
+	if got, _ := c.args(CapPair, CommandData{Code: "123"}); !reflect.DeepEqual(got, []string{"pair-tool", "--code=123"}) {
+		t.Fatalf("pair args = %q", got)
+	}
+	if _, err := c.args(CapPlay, CommandData{URL: "u"}); !errors.Is(err, ErrNoDevice) {
+		t.Fatalf("expected ErrNoDevice, got %v", err)
+	}
+	if _, err := c.args(CapQueue, CommandData{}); !errors.Is(err, ErrNotSupported) {
+		t.Fatalf("expected ErrNotSupported, got %v", err)
+	}
+	if caps := c.Capabilities(); !reflect.DeepEqual(caps, []Capability{CapPlay, CapPair}) {
+		t.Fatalf("capabilities = %v", caps)
+	}
+}
+
+func TestCommand_ConfigErrors(t *testing.T) {
+	for name, cfg := range map[string]CommandConfig{
+		"no commands":   {},
+		"empty argv":    {Play: []string{}},
+		"bad template":  {Play: []string{"x", "{{.URL"}},
+		"unknown field": {Play: []string{"x", "{{.Volume}}"}},
+		"bad devices":   {List: []string{"x"}, Devices: "("},
+		"bad fail":      {Play: []string{"x"}, Fail: "["},
+	} {
+		if _, err := NewCommand("c", cfg); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+
+	var cfg Config
+	conf := `{
+	  "casters": [{"name": "mpv", "type": "command", "play": ["mpv", "{{.URL}}"],
+	               "timeout": "1m", "env": {"DISPLAY": ":0"}, "dir": "/tmp"}],
+	  "routes": [{"caster": "mpv"}]
+	}`
+	if err := json.Unmarshal([]byte(conf), &cfg); err != nil {
+		t.Fatal(err)
+	}
+	r, err := cfg.Router()
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, ok := r.Caster("mpv").(*Command)
+	if !ok || c.Dir != "/tmp" || !reflect.DeepEqual(c.Env, []string{"DISPLAY=:0"}) || c.Timeout.Minutes() != 1 {
+		t.Fatalf("mpv caster = %#v", r.Caster("mpv"))
+	}
+}
+
+func TestParseDevices(t *testing.T) {
+	out := "Scanning...\r\n192.168.1.10 - Living Room - Chromecast\n\n192.168.1.11 - Kitchen - Google Home\n"
+	re := regexp.MustCompile(`^(?P<id>[\d.]+) - (?P<name>[^-]+?) - `)
+	got := parseDevices(out, re)
+	want := []Device{{ID: "192.168.1.10", Name: "Living Room"}, {ID: "192.168.1.11", Name: "Kitchen"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("devices = %+v, want %+v", got, want)
+	}
+	if got := parseDevices("a\n b \n", nil); !reflect.DeepEqual(got, []Device{{Name: "a"}, {Name: " b "}}) {
+		t.Fatalf("plain devices = %+v", got)
+	}
+}
@@ -0,0 +1,243 @@
+package cast
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	nethttp "net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/claes/ytplv/internal/source"
+)
+
+// DefaultKodiPort is the port of Kodi's web server unless configured.
+const DefaultKodiPort = 8080
+
+// kodiVideoPlaylist is the id of Kodi's video playlist.
+const kodiVideoPlaylist = 1
+
+func init() {
+	RegisterType("kodi", func(name string, spec json.RawMessage) (Caster, error) {
+		var c struct {
+			Name     string   `json:"name"`
+			Type     string   `json:"type"`
+			Host     string   `json:"host"` // required
+			Port     int      `json:"port"` // default DefaultKodiPort
+			User     string   `json:"user"` // web server credentials, if set in Kodi
+			Password string   `json:"password"`
+			Timeout  Duration `json:"timeout"` // per call; default 10s
+		}
+		if err := decodeSpec(spec, &c); err != nil {
+			return nil, err
+		}
+		if c.Host == "" {
+			return nil, fmt.Errorf("host is required")
+		}
+		k := NewKodi(name, c.Host, c.Port)
+		k.User, k.Password = c.User, c.Password
+		k.Timeout = c.Timeout.or(k.Timeout)
+		return k, nil
+	})
+}
+
+// Kodi plays items on a Kodi box through its JSON-RPC API (Settings >
+// Services > Control > Allow remote control via HTTP). It has no notion of
+// devices; route devices to separate Kodi casters instead.
+type Kodi struct {
+	name     string
+	URL      string // JSON-RPC endpoint
+	User     string
+	Password string
+	Timeout  time.Duration
+	Client   *nethttp.Client
+}
+
+// NewKodi returns a caster for the Kodi web server at host:port (0 means
+// DefaultKodiPort).
+func NewKodi(name, host string, port int) *Kodi {
+	if port == 0 {
+		port = DefaultKodiPort
+	}
+	u := url.URL{Scheme: "http", Host: net.JoinHostPort(host, strconv.Itoa(port)), Path: "/jsonrpc"}
+	return &Kodi{name: name, URL: u.String(), Timeout: 10 * time.Second, Client: nethttp.DefaultClient}
+}
+
+func (k *Kodi) Name() string { return k.name }
+
+func (k *Kodi) Capabilities() []Capability {
+	return []Capability{CapPlay, CapQueue, CapList, CapStop, CapPause}
+}
+
+// Play opens the item with Player.Open, resuming at the start offset of its
+// URL if there is one.
+func (k *Kodi) Play(ctx context.Context, device string, m Media) error {
+	file := kodiFile(m)
+	params := map[string]any{"item": map[string]string{"file": file}}
+	start := source.StartOffset(m.URL)
+	if start > 0 {
+		params["options"] = map[string]any{"resume": map[string]int{
+			"hours":   start / 3600,
+			"minutes": start / 60 % 60,
+			"seconds": start % 60,
+		}}
+	}
+	slog.Info("kodi open", "caster", k.name, "file", file, "start", start)
+	return k.call(ctx, CapPlay, "Player.Open", params, nil)
+}
+
+// Queue appends the item to the video playlist with Playlist.Add.
+func (k *Kodi) Queue(ctx context.Context, device string, m Media) error {
+	file := kodiFile(m)
+	slog.Info("kodi queue", "caster", k.name, "file", file)
+	return k.call(ctx, CapQueue, "Playlist.Add", map[string]any{
+		"playlistid": kodiVideoPlaylist,
+		"item":       map[string]string{"file": file},
+	}, nil)
+}
+
+func (k *Kodi) Pair(context.Context, string) error { return ErrNotSupported }
+
+// ListDevices reports the Kodi box itself, named after its version.
+func (k *Kodi) ListDevices(ctx context.Context) ([]Device, error) {
+	var props struct {
+		Name    string `json:"name"`
+		Version struct {
+			Major int `json:"major"`
+			Minor int `json:"minor"`
+		} `json:"version"`
+	}
+	if err := k.call(ctx, CapList, "Application.GetProperties", map[string]any{"properties": []string{"name", "version"}}, &props); err != nil {
+		return nil, err
+	}
+	host := k.URL
+	if u, err := url.Parse(k.URL); err == nil {
+		host = u.Host
+	}
+	return []Device{{Name: fmt.Sprintf("%s %d.%d at %s", props.Name, props.Version.Major, props.Version.Minor, host)}}, nil
+}
+
+// Stop stops the active player with Player.Stop. Stopping when nothing plays
+// is not an error.
+func (k *Kodi) Stop(ctx context.Context, device string) error {
+	id, err := k.activePlayer(ctx, CapStop)
+	if errors.Is(err, ErrNotPlaying) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return k.call(ctx, CapStop, "Player.Stop", map[string]any{"playerid": id}, nil)
+}
+
+// PlayPause toggles pause of the active player with Player.PlayPause.
+func (k *Kodi) PlayPause(ctx context.Context, device string) error {
+	id, err := k.activePlayer(ctx, CapPause)
+	if err != nil {
+		return err
+	}
+	return k.call(ctx, CapPause, "Player.PlayPause", map[string]any{"playerid": id}, nil)
+}
+
+// activePlayer returns the id of the video player, or else of the first
+// active player.
+func (k *Kodi) activePlayer(ctx context.Context, op Capability) (int, error) {
+	var players []struct {
+		ID   int    `json:"playerid"`
+		Type string `json:"type"`
+	}
+	if err := k.call(ctx, op, "Player.GetActivePlayers", nil, &players); err != nil {
+		return 0, err
+	}
+	if len(players) == 0 {
+		return 0, ErrNotPlaying
+	}
+	for _, p := range players {
+		if p.Type == "video" {
+			return p.ID, nil
+		}
+	}
+	return players[0].ID, nil
+}
+
+// kodiFile returns what Kodi should open for m: the original plugin:// line
+// of the item's STRM, else the YouTube add-on URL for YouTube videos, else
+// the URL itself.
+func kodiFile(m Media) string {
+	if strings.HasPrefix(strings.ToLower(m.STRM), "plugin://") {
+		return m.STRM
+	}
+	if typ, id := source.Parse(m.URL); typ == source.YouTube.Name() && id != "" {
+		return "plugin://plugin.video.youtube/play/?video_id=" + url.QueryEscape(id)
+	}
+	return m.URL
+}
+
+type kodiRequest struct {
+	JSONRPC string `json:"jsonrpc"`
+	ID      int    `json:"id"`
+	Method  string `json:"method"`
+	Params  any    `json:"params,omitempty"`
+}
+
+type kodiResponse struct {
+	Result json.RawMessage `json:"result"`
+	Error  *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// call invokes method with params and decodes the result into result unless
+// it is nil.
+func (k *Kodi) call(ctx context.Context, op Capability, method string, params, result any) error {
+	fail := func(err error) error {
+		slog.Error("kodi call failed", "caster", k.name, "method", method, "err", err)
+		return &Error{Caster: k.name, Op: op, Remote: true, Err: err}
+	}
+	body, err := json.Marshal(kodiRequest{JSONRPC: "2.0", ID: 1, Method: method, Params: params})
+	if err != nil {
+		return fail(err)
+	}
+	ctx, cancel := context.WithTimeout(ctx, k.Timeout)
+	defer cancel()
+	req, err := nethttp.NewRequestWithContext(ctx, nethttp.MethodPost, k.URL, bytes.NewReader(body))
+	if err != nil {
+		return fail(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if k.User != "" || k.Password != "" {
+		req.SetBasicAuth(k.User, k.Password)
+	}
+	slog.Debug("kodi call", "caster", k.name, "method", method)
+	resp, err := k.Client.Do(req)
+	if err != nil {
+		return fail(err)
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode == nethttp.StatusUnauthorized:
+		return fail(fmt.Errorf("unauthorized; check user and password"))
+	case resp.StatusCode != nethttp.StatusOK:
+		return fail(fmt.Errorf("status %d", resp.StatusCode))
+	}
+	var r kodiResponse
+	if err := json.NewDecoder(resp.Body).Decode(&r); err != nil {
+		return fail(fmt.Errorf("decode response: %w", err))
+	}
+	if r.Error != nil {
+		return fail(fmt.Errorf("%s (code %d)", r.Error.Message, r.Error.Code))
+	}
+	if result != nil {
+		if err := json.Unmarshal(r.Result, result); err != nil {
+			return fail(fmt.Errorf("decode result: %w", err))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,185 @@
+package cast
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	nethttp "net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// kodiStub is a JSON-RPC server standing in for Kodi. It records the
+// requests it gets and answers from results, keyed by method.
+type kodiStub struct {
+	*httptest.Server
+	calls   []string
+	params  []json.RawMessage
+	results map[string]string
+}
+
+func newKodiStub(t *testing.T) *kodiStub {
+	s := &kodiStub{results: map[string]string{}}
+	s.Server = httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		if u, p, ok := r.BasicAuth(); !ok || u != "kodi" || p != "secret" {
+			w.WriteHeader(nethttp.StatusUnauthorized)
+			return
+		}
+		var req struct {
+			JSONRPC string          `json:"jsonrpc"`
+			Method  string          `json:"method"`
+			Params  json.RawMessage `json:"params"`
+		}
+		if r.Method != nethttp.MethodPost || r.URL.Path != "/jsonrpc" || json.NewDecoder(r.Body).Decode(&req) != nil || req.JSONRPC != "2.0" {
+			t.Errorf("bad request %s %s", r.Method, r.URL)
+			w.WriteHeader(nethttp.StatusBadRequest)
+			return
+		}
+		s.calls = append(s.calls, req.Method)
+		s.params = append(s.params, req.Params)
+		res, ok := s.results[req.Method]
+		if !ok {
+			res = `"OK"`
+		}
+		if strings.HasPrefix(res, "error:") {
+			_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"error":{"code":-32602,"message":"` + strings.TrimPrefix(res, "error:") + `"}}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":` + res + `}`))
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *kodiStub) kodi() *Kodi {
+	k := NewKodi("kodi", "", 0)
+	k.URL = s.URL + "/jsonrpc"
+	k.User, k.Password = "kodi", "secret"
+	return k
+}
+
+func TestKodi_PlayAndQueue(t *testing.T) {
+	stub := newKodiStub(t)
+	k := stub.kodi()
+	ctx := context.Background()
+
+	strm := "plugin://plugin.video.youtube/play/?video_id=abc&t=90"
+	if err := k.Play(ctx, "", Media{URL: "https://www.youtube.com/watch?v=abc&t=90", STRM: strm}); err != nil {
+		t.Fatal(err)
+	}
+	if err := k.Queue(ctx, "", Media{URL: "https://youtu.be/def"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := k.Play(ctx, "", Media{URL: "https://example.com/v.mp4"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := k.Play(ctx, "", Media{URL: "https://www.youtube.com/watch?v=ghi&t=3723"}); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		`{"item":{"file":"plugin://plugin.video.youtube/play/?video_id=abc\u0026t=90"},"options":{"resume":{"hours":0,"minutes":1,"seconds":30}}}`, // & is escaped
+		`{"item":{"file":"plugin://plugin.video.youtube/play/?video_id=def"},"playlistid":1}`,
+		`{"item":{"file":"https://example.com/v.mp4"}}`,
+		`{"item":{"file":"plugin://plugin.video.youtube/play/?video_id=ghi"},"options":{"resume":{"hours":1,"minutes":2,"seconds":3}}}`, // offset from the URL
+	}
+	if strings.Join(stub.calls, " ") != "Player.Open Playlist.Add Player.Open Player.Open" {
+		t.Fatalf("calls = %v", stub.calls)
+	}
+	for i, w := range want {
+		if got := string(stub.params[i]); got != w {
+			t.Errorf("params[%d] = %s, want %s", i, got, w)
+		}
+	}
+}
+
+func TestKodi_Control(t *testing.T) {
+	stub := newKodiStub(t)
+	k := stub.kodi()
+	ctx := context.Background()
+
+	stub.results["Player.GetActivePlayers"] = `[{"playerid":0,"type":"audio"},{"playerid":1,"type":"video"}]`
+	if err := k.PlayPause(ctx, ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := k.Stop(ctx, ""); err != nil {
+		t.Fatal(err)
+	}
+	if got := string(stub.params[len(stub.params)-1]); got != `{"playerid":1}` {
+		t.Fatalf("stop params = %s", got)
+	}
+
+	stub.results["Player.GetActivePlayers"] = `[]`
+	stub.calls = nil
+	if err := k.Stop(ctx, ""); err != nil {
+		t.Fatalf("stop with nothing playing: %v", err)
+	}
+	if err := k.PlayPause(ctx, ""); !errors.Is(err, ErrNotPlaying) {
+		t.Fatalf("expected ErrNotPlaying, got %v", err)
+	}
+	if strings.Join(stub.calls, " ") != "Player.GetActivePlayers Player.GetActivePlayers" {
+		t.Fatalf("calls = %v", stub.calls)
+	}
+}
+
+func TestKodi_Errors(t *testing.T) {
+	stub := newKodiStub(t)
+	k := stub.kodi()
+	ctx := context.Background()
+
+	stub.results["Player.Open"] = "error:Invalid params."
+	var ce *Error
+	err := k.Play(ctx, "", Media{URL: "https://youtu.be/x"})
+	if !errors.As(err, &ce) || !ce.Remote || !strings.Contains(err.Error(), "Invalid params.") {
+		t.Fatalf("expected remote JSON-RPC error, got %v", err)
+	}
+
+	k.Password = "wrong"
+	if err := k.Queue(ctx, "", Media{URL: "https://youtu.be/x"}); err == nil || !strings.Contains(err.Error(), "unauthorized") {
+		t.Fatalf("expected unauthorized, got %v", err)
+	}
+	if err := k.Pair(ctx, "1234"); !errors.Is(err, ErrNotSupported) {
+		t.Fatalf("expected ErrNotSupported, got %v", err)
+	}
+}
+
+func TestKodi_ListDevicesAndConfig(t *testing.T) {
+	stub := newKodiStub(t)
+	stub.results["Application.GetProperties"] = `{"name":"Kodi","version":{"major":21,"minor":1}}`
+	devs, err := stub.kodi().ListDevices(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(devs) != 1 || !strings.HasPrefix(devs[0].Name, "Kodi 21.1 at 127.0.0.1:") {
+		t.Fatalf("devices = %+v", devs)
+	}
+
+	var cfg Config
+	conf := `{"casters": [{"name": "den", "type": "kodi", "host": "den.lan", "user": "kodi", "password": "pw"}], "routes": [{"caster": "den"}]}`
+	if err := json.Unmarshal([]byte(conf), &cfg); err != nil {
+		t.Fatal(err)
+	}
+	r, err := cfg.Router()
+	if err != nil {
+		t.Fatal(err)
+	}
+	k := r.Caster("den").(*Kodi)
+	if k.URL != "http://den.lan:8080/jsonrpc" || k.User != "kodi" || k.Password != "pw" {
+		t.Fatalf("kodi caster = %+v", k)
+	}
+	cfg.Casters[0] = json.RawMessage(`{"name": "den", "type": "kodi"}`)
+	if _, err := cfg.Router(); err == nil {
+		t.Fatalf("expected error without host")
+	}
+}
+
+func TestNewKodi_URL(t *testing.T) {
+	for host, want := range map[string]string{
+		"kodi.local": "http://kodi.local:8080/jsonrpc",
+		"fe80::1":    "http://[fe80::1]:8080/jsonrpc",
+	} {
+		if got := NewKodi("kodi", host, 0).URL; got != want {
+			t.Errorf("NewKodi(%q).URL = %s, want %s", host, got, want)
+		}
+	}
+}
@@ -0,0 +1,197 @@
+package cast
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	nethttp "net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Roku ECP defaults.
+const (
+	DefaultRokuPort    = 8060
+	DefaultRokuChannel = "837" // the YouTube channel
+)
+
+func init() {
+	RegisterType("roku", func(name string, spec json.RawMessage) (Caster, error) {
+		var c struct {
+			Name    string   `json:"name"`
+			Type    string   `json:"type"`
+			Host    string   `json:"host"`    // required
+			Port    int      `json:"port"`    // default DefaultRokuPort
+			Channel string   `json:"channel"` // YouTube channel id; default DefaultRokuChannel
+			Timeout Duration `json:"timeout"` // per call; default 10s
+		}
+		if err := decodeSpec(spec, &c); err != nil {
+			return nil, err
+		}
+		if c.Host == "" {
+			return nil, fmt.Errorf("host is required")
+		}
+		r := NewRoku(name, c.Host, c.Port)
+		if c.Channel != "" {
+			r.Channel = c.Channel
+		}
+		r.Timeout = c.Timeout.or(r.Timeout)
+		return r, nil
+	})
+}
+
+// Roku plays YouTube videos on a Roku through its External Control Protocol
+// (Settings > System > Advanced system settings > Control by mobile apps must
+// allow it). It deep links into the YouTube channel and has no queue. Like
+// Kodi, it has no notion of devices; route each Roku to its own caster.
+type Roku struct {
+	name    string
+	URL     string // ECP base URL, without a trailing slash
+	Channel string
+	Timeout time.Duration
+	Client  *nethttp.Client
+}
+
+// NewRoku returns a caster for the Roku at host:port (0 means
+// DefaultRokuPort).
+func NewRoku(name, host string, port int) *Roku {
+	if port == 0 {
+		port = DefaultRokuPort
+	}
+	u := url.URL{Scheme: "http", Host: net.JoinHostPort(host, strconv.Itoa(port))}
+	return &Roku{name: name, URL: u.String(), Channel: DefaultRokuChannel, Timeout: 10 * time.Second, Client: nethttp.DefaultClient}
+}
+
+func (r *Roku) Name() string { return r.name }
+
+func (r *Roku) Capabilities() []Capability {
+	return []Capability{CapPlay, CapList, CapStop, CapPause}
+}
+
+// Play launches the YouTube channel with the video of m as contentId.
+func (r *Roku) Play(ctx context.Context, device string, m Media) error {
+	id, _, err := youtubeVideo(m.URL)
+	if err != nil {
+		return err
+	}
+	slog.Info("roku launch", "caster", r.name, "channel", r.Channel, "video", id)
+	q := url.Values{"contentId": {id}, "mediaType": {"movie"}}
+	_, err = r.call(ctx, CapPlay, nethttp.MethodPost, "/launch/"+url.PathEscape(r.Channel)+"?"+q.Encode())
+	return err
+}
+
+func (r *Roku) Queue(context.Context, string, Media) error { return ErrNotSupported }
+
+func (r *Roku) Pair(context.Context, string) error { return ErrNotSupported }
+
+// ListDevices reports the Roku itself, from query/device-info.
+func (r *Roku) ListDevices(ctx context.Context) ([]Device, error) {
+	body, err := r.call(ctx, CapList, nethttp.MethodGet, "/query/device-info")
+	if err != nil {
+		return nil, err
+	}
+	var info struct {
+		Serial       string `xml:"serial-number"`
+		UserName     string `xml:"user-device-name"`
+		FriendlyName string `xml:"friendly-device-name"`
+		Model        string `xml:"model-name"`
+	}
+	if err := xml.Unmarshal(body, &info); err != nil {
+		return nil, r.fail(CapList, fmt.Errorf("decode device-info: %w", err))
+	}
+	name := info.UserName
+	if name == "" {
+		name = info.FriendlyName
+	}
+	if name == "" {
+		name = "Roku " + info.Serial
+	}
+	host := r.URL
+	if u, err := url.Parse(r.URL); err == nil {
+		host = u.Host
+	}
+	return []Device{{Name: fmt.Sprintf("%s (%s) at %s", name, info.Model, host)}}, nil
+}
+
+// Stop leaves the player with the Back key. Stopping when nothing plays is
+// not an error and presses nothing.
+func (r *Roku) Stop(ctx context.Context, device string) error {
+	playing, err := r.playing(ctx, CapStop)
+	if err != nil || !playing {
+		return err
+	}
+	_, err = r.call(ctx, CapStop, nethttp.MethodPost, "/keypress/Back")
+	return err
+}
+
+// PlayPause toggles pause with the Play key.
+func (r *Roku) PlayPause(ctx context.Context, device string) error {
+	playing, err := r.playing(ctx, CapPause)
+	if err != nil {
+		return err
+	}
+	if !playing {
+		return ErrNotPlaying
+	}
+	_, err = r.call(ctx, CapPause, nethttp.MethodPost, "/keypress/Play")
+	return err
+}
+
+// playing reports whether the media player is playing or paused, from
+// query/media-player.
+func (r *Roku) playing(ctx context.Context, op Capability) (bool, error) {
+	body, err := r.call(ctx, op, nethttp.MethodGet, "/query/media-player")
+	if err != nil {
+		return false, err
+	}
+	var player struct {
+		State string `xml:"state,attr"`
+	}
+	if err := xml.Unmarshal(body, &player); err != nil {
+		return false, r.fail(op, fmt.Errorf("decode media-player: %w", err))
+	}
+	switch player.State {
+	case "play", "pause", "buffer", "startup":
+		return true, nil
+	}
+	return false, nil
+}
+
+// call sends an ECP request and returns the response body.
+func (r *Roku) call(ctx context.Context, op Capability, method, path string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.Timeout)
+	defer cancel()
+	req, err := nethttp.NewRequestWithContext(ctx, method, r.URL+path, nil)
+	if err != nil {
+		return nil, r.fail(op, err)
+	}
+	slog.Debug("roku call", "caster", r.name, "method", method, "path", path)
+	resp, err := r.Client.Do(req)
+	if err != nil {
+		return nil, r.fail(op, err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	switch {
+	case resp.StatusCode == nethttp.StatusForbidden:
+		return nil, r.fail(op, fmt.Errorf("forbidden; allow control by mobile apps on the Roku"))
+	case resp.StatusCode == nethttp.StatusNotFound && op == CapPlay:
+		return nil, r.fail(op, fmt.Errorf("channel %s not installed", r.Channel))
+	case resp.StatusCode < 200 || resp.StatusCode >= 300:
+		return nil, r.fail(op, fmt.Errorf("%s %s: status %d", method, path, resp.StatusCode))
+	case err != nil:
+		return nil, r.fail(op, err)
+	}
+	return body, nil
+}
+
+// fail logs err and wraps it in an *Error.
+func (r *Roku) fail(op Capability, err error) error {
+	slog.Error("roku call failed", "caster", r.name, "op", op, "err", err)
+	return &Error{Caster: r.name, Op: op, Remote: true, Err: err}
+}
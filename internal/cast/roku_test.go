@@ -0,0 +1,141 @@
+package cast
+
+import (
+	"context"
+	"errors"
+	nethttp "net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// rokuStub is an HTTP server standing in for a Roku's ECP. It records the
+// requests it gets and answers queries with the player state.
+type rokuStub struct {
+	*httptest.Server
+	calls    []string
+	state    string // media-player state
+	channels map[string]bool
+	limited  bool // "Control by mobile apps" disabled
+}
+
+func newRokuStub(t *testing.T) *rokuStub {
+	s := &rokuStub{state: "close", channels: map[string]bool{"837": true}}
+	s.Server = httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		if s.limited {
+			w.WriteHeader(nethttp.StatusForbidden)
+			return
+		}
+		switch {
+		case r.Method == nethttp.MethodGet && r.URL.Path == "/query/device-info":
+			_, _ = w.Write([]byte(`<?xml version="1.0" encoding="UTF-8" ?>
+<device-info>
+	<udn>28001240-0000-1000-8000-d8313400f9b1</udn>
+	<serial-number>X004000AB123</serial-number>
+	<model-name>Roku Ultra</model-name>
+	<friendly-device-name>Roku Ultra - X004000AB123</friendly-device-name>
+	<user-device-name>Bedroom</user-device-name>
+	<power-mode>PowerOn</power-mode>
+</device-info>`))
+			return
+		case r.Method == nethttp.MethodGet && r.URL.Path == "/query/media-player":
+			_, _ = w.Write([]byte(`<player error="false" state="` + s.state + `"/>`))
+			return
+		case r.Method != nethttp.MethodPost:
+			t.Errorf("bad request %s %s", r.Method, r.URL)
+			w.WriteHeader(nethttp.StatusMethodNotAllowed)
+			return
+		}
+		if ch, ok := strings.CutPrefix(r.URL.Path, "/launch/"); ok && !s.channels[ch] {
+			w.WriteHeader(nethttp.StatusNotFound)
+			return
+		}
+		s.calls = append(s.calls, r.URL.RequestURI())
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *rokuStub) roku() *Roku {
+	r := NewRoku("roku", "", 0)
+	r.URL = s.URL
+	return r
+}
+
+func TestRoku_Play(t *testing.T) {
+	stub := newRokuStub(t)
+	r := stub.roku()
+	ctx := context.Background()
+
+	if err := r.Play(ctx, "", Media{URL: "https://www.youtube.com/watch?v=abc&t=90"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Play(ctx, "", Media{URL: "https://www.svtplay.se/video/x"}); !errors.Is(err, ErrUnsupportedURL) {
+		t.Fatalf("expected ErrUnsupportedURL, got %v", err)
+	}
+	if err := r.Queue(ctx, "", Media{URL: "https://youtu.be/abc"}); !errors.Is(err, ErrNotSupported) {
+		t.Fatalf("expected ErrNotSupported for queue, got %v", err)
+	}
+	if strings.Join(stub.calls, " ") != "/launch/837?contentId=abc&mediaType=movie" {
+		t.Fatalf("calls = %v", stub.calls)
+	}
+
+	r.Channel = "999"
+	var ce *Error
+	if err := r.Play(ctx, "", Media{URL: "https://youtu.be/abc"}); !errors.As(err, &ce) || !ce.Remote || !strings.Contains(err.Error(), "not installed") {
+		t.Fatalf("expected remote error for a missing channel, got %v", err)
+	}
+	stub.limited = true
+	if err := r.Play(ctx, "", Media{URL: "https://youtu.be/abc"}); err == nil || !strings.Contains(err.Error(), "mobile apps") {
+		t.Fatalf("expected forbidden hint, got %v", err)
+	}
+}
+
+func TestRoku_Control(t *testing.T) {
+	stub := newRokuStub(t)
+	r := stub.roku()
+	ctx := context.Background()
+
+	if err := r.Stop(ctx, ""); err != nil {
+		t.Fatalf("stop with nothing playing: %v", err)
+	}
+	if err := r.PlayPause(ctx, ""); !errors.Is(err, ErrNotPlaying) {
+		t.Fatalf("expected ErrNotPlaying, got %v", err)
+	}
+	if len(stub.calls) != 0 {
+		t.Fatalf("pressed keys with nothing playing: %v", stub.calls)
+	}
+	stub.state = "play"
+	if err := r.PlayPause(ctx, ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Stop(ctx, ""); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(stub.calls, " ") != "/keypress/Play /keypress/Back" {
+		t.Fatalf("calls = %v", stub.calls)
+	}
+}
+
+func TestRoku_ListDevices(t *testing.T) {
+	stub := newRokuStub(t)
+	devs, err := stub.roku().ListDevices(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	host := strings.TrimPrefix(stub.URL, "http://")
+	if len(devs) != 1 || devs[0].Name != "Bedroom (Roku Ultra) at "+host {
+		t.Fatalf("devices = %+v", devs)
+	}
+}
+
+func TestNewRoku_URL(t *testing.T) {
+	for host, want := range map[string]string{
+		"roku.local": "http://roku.local:8060",
+		"fe80::1":    "http://[fe80::1]:8060",
+	} {
+		if got := NewRoku("roku", host, 0).URL; got != want {
+			t.Errorf("NewRoku(%q).URL = %s, want %s", host, got, want)
+		}
+	}
+}
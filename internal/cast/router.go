@@ -0,0 +1,198 @@
+package cast
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Route sends requests for items of a source type, cast to a device, to the
+// caster named Caster. Empty Source and Device match anything.
+type Route struct {
+	Source string `json:"source,omitempty"` // source type, e.g. "youtube"
+	Device string `json:"device,omitempty"` // device as passed to Play and Queue
+	Caster string `json:"caster"`
+}
+
+func (r Route) matches(source, device string) bool {
+	return (r.Source == "" || r.Source == source) && (r.Device == "" || r.Device == device)
+}
+
+// Router picks the caster for each request.
+type Router struct {
+	casters map[string]Caster
+	routes  []Route
+}
+
+// NewRouter returns a router over casters, trying routes in order. Every
+// route must name one of the casters.
+func NewRouter(casters []Caster, routes []Route) (*Router, error) {
+	r := &Router{casters: make(map[string]Caster, len(casters)), routes: routes}
+	for _, c := range casters {
+		if _, dup := r.casters[c.Name()]; dup {
+			return nil, fmt.Errorf("duplicate caster %q", c.Name())
+		}
+		r.casters[c.Name()] = c
+	}
+	for _, rt := range routes {
+		if r.casters[rt.Caster] == nil {
+			return nil, fmt.Errorf("route to unknown caster %q", rt.Caster)
+		}
+	}
+	return r, nil
+}
+
+// Pick returns the caster of the first route matching source and device, or
+// nil when none does.
+func (r *Router) Pick(source, device string) Caster {
+	for _, rt := range r.routes {
+		if rt.matches(source, device) {
+			return r.casters[rt.Caster]
+		}
+	}
+	return nil
+}
+
+// Caster returns the caster called name, or nil.
+func (r *Router) Caster(name string) Caster {
+	return r.casters[name]
+}
+
+// Casters returns the casters of r, sorted by name.
+func (r *Router) Casters() []Caster {
+	out := make([]Caster, 0, len(r.casters))
+	for _, c := range r.casters {
+		out = append(out, c)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name() < out[j].Name() })
+	return out
+}
+
+// Config is the content of a cast configuration file:
+//
+//	{
+//	  "casters": [
+//	    {"name": "ytcast", "type": "ytcast"},
+//	    {"name": "svt", "type": "svtplay", "endpoint": "http://localhost:18492/play"}
+//	  ],
+//	  "routes": [
+//	    {"source": "svtplay", "caster": "svt"},
+//	    {"caster": "ytcast"}
+//	  ]
+//	}
+//
+// Each caster entry holds "name", "type" and the settings of that type.
+type Config struct {
+	Casters []json.RawMessage `json:"casters"`
+	Routes  []Route           `json:"routes"`
+}
+
+// Factory builds a caster of one type from its configuration entry.
+type Factory func(name string, spec json.RawMessage) (Caster, error)
+
+var factories = map[string]Factory{}
+
+// RegisterType makes casters of type typ available to configuration files.
+func RegisterType(typ string, f Factory) {
+	factories[typ] = f
+}
+
+// Types returns the registered caster types, sorted.
+func Types() []string {
+	out := make([]string, 0, len(factories))
+	for t := range factories {
+		out = append(out, t)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// LoadConfig reads a cast configuration file and builds its router.
+func LoadConfig(path string) (*Router, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var cfg Config
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.DisallowUnknownFields()
+	if err := d.Decode(&cfg); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	r, err := cfg.Router()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return r, nil
+}
+
+// Router builds the casters and routes described by cfg.
+func (cfg Config) Router() (*Router, error) {
+	casters := make([]Caster, 0, len(cfg.Casters))
+	for i, spec := range cfg.Casters {
+		var head struct {
+			Name string `json:"name"`
+			Type string `json:"type"`
+		}
+		if err := json.Unmarshal(spec, &head); err != nil {
+			return nil, fmt.Errorf("caster %d: %w", i, err)
+		}
+		f := factories[head.Type]
+		if f == nil {
+			return nil, fmt.Errorf("caster %d: unknown type %q (known: %s)", i, head.Type, strings.Join(Types(), ", "))
+		}
+		if head.Name == "" {
+			head.Name = head.Type
+		}
+		c, err := f(head.Name, spec)
+		if err != nil {
+			return nil, fmt.Errorf("caster %q: %w", head.Name, err)
+		}
+		casters = append(casters, c)
+	}
+	if len(cfg.Routes) == 0 {
+		return nil, fmt.Errorf("no routes")
+	}
+	return NewRouter(casters, cfg.Routes)
+}
+
+// decodeSpec decodes a caster entry into v, which embeds or repeats the
+// "name" and "type" keys, rejecting unknown keys.
+func decodeSpec(spec json.RawMessage, v any) error {
+	d := json.NewDecoder(bytes.NewReader(spec))
+	d.DisallowUnknownFields()
+	return d.Decode(v)
+}
+
+// Duration is a time.Duration written as a string such as "15s" in
+// configuration files.
+type Duration time.Duration
+
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// or returns d, or def when d is not positive.
+func (d Duration) or(def time.Duration) time.Duration {
+	if d <= 0 {
+		return def
+	}
+	return time.Duration(d)
+}
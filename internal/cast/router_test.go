@@ -0,0 +1,109 @@
+package cast
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fake is a caster recording its calls.
+type fake struct {
+	name  string
+	caps  []Capability
+	calls []string
+}
+
+func (f *fake) Name() string               { return f.name }
+func (f *fake) Capabilities() []Capability { return f.caps }
+func (f *fake) Play(_ context.Context, device string, m Media) error {
+	f.calls = append(f.calls, "play "+device+" "+m.URL)
+	return nil
+}
+func (f *fake) Queue(_ context.Context, device string, m Media) error {
+	f.calls = append(f.calls, "queue "+device+" "+m.URL)
+	return nil
+}
+func (f *fake) Pair(context.Context, string) error            { return ErrNotSupported }
+func (f *fake) ListDevices(context.Context) ([]Device, error) { return nil, ErrNotSupported }
+
+func TestRouter_Pick(t *testing.T) {
+	a, b, c := &fake{name: "a"}, &fake{name: "b"}, &fake{name: "c"}
+	r, err := NewRouter([]Caster{a, b, c}, []Route{
+		{Source: "svtplay", Caster: "b"},
+		{Device: "kids-tv", Caster: "c"},
+		{Caster: "a"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct{ source, device, want string }{
+		{"svtplay", "kids-tv", "b"},
+		{"youtube", "kids-tv", "c"},
+		{"youtube", "tv", "a"},
+	} {
+		if got := r.Pick(tc.source, tc.device); got.Name() != tc.want {
+			t.Errorf("Pick(%q, %q) = %s, want %s", tc.source, tc.device, got.Name(), tc.want)
+		}
+	}
+
+	r, _ = NewRouter([]Caster{a}, []Route{{Source: "youtube", Caster: "a"}})
+	if r.Pick("svtplay", "") != nil {
+		t.Errorf("expected no caster for unrouted source")
+	}
+	if _, err := NewRouter([]Caster{a}, []Route{{Caster: "missing"}}); err == nil {
+		t.Errorf("expected error for route to unknown caster")
+	}
+	if _, err := NewRouter([]Caster{a, a}, nil); err == nil {
+		t.Errorf("expected error for duplicate caster")
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cast.json")
+	conf := `{
+	  "casters": [
+	    {"name": "yt", "type": "ytcast", "bin": "/opt/ytcast", "timeout": "3s"},
+	    {"type": "svtplay", "endpoint": "http://svt.local/play"}
+	  ],
+	  "routes": [
+	    {"source": "svtplay", "caster": "svtplay"},
+	    {"caster": "yt"}
+	  ]
+	}`
+	if err := os.WriteFile(path, []byte(conf), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	r, err := LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	yt, ok := r.Pick("youtube", "").(*Ytcast)
+	if !ok || yt.argv[CapPlay][0].Root.String() != "/opt/ytcast" || yt.Timeout != 3*time.Second {
+		t.Fatalf("youtube caster = %#v", r.Pick("youtube", ""))
+	}
+	svt, ok := r.Pick("svtplay", "").(*SVTPlay)
+	if !ok || svt.Endpoint != "http://svt.local/play" || svt.Name() != "svtplay" {
+		t.Fatalf("svtplay caster = %#v", r.Pick("svtplay", ""))
+	}
+
+	for name, bad := range map[string]string{
+		"unknown type":  `{"casters": [{"type": "telepathy"}], "routes": [{"caster": "telepathy"}]}`,
+		"unknown field": `{"casters": [{"type": "ytcast", "colour": "red"}], "routes": [{"caster": "ytcast"}]}`,
+		"bad duration":  `{"casters": [{"type": "ytcast", "timeout": "soon"}], "routes": [{"caster": "ytcast"}]}`,
+		"no routes":     `{"casters": [{"type": "ytcast"}]}`,
+	} {
+		var cfg Config
+		if err := json.Unmarshal([]byte(bad), &cfg); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if _, err := cfg.Router(); err == nil {
+			t.Errorf("%s: expected error", name)
+		} else if name == "unknown type" && !strings.Contains(err.Error(), "ytcast") {
+			t.Errorf("%s: error should list known types: %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package cast
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	nethttp "net/http"
+	"net/url"
+	"time"
+)
+
+// DefaultSVTEndpoint is where SVT Play URLs are forwarded unless configured.
+const DefaultSVTEndpoint = "http://localhost:18492/play"
+
+func init() {
+	RegisterType("svtplay", func(name string, spec json.RawMessage) (Caster, error) {
+		var c struct {
+			Name     string   `json:"name"`
+			Type     string   `json:"type"`
+			Endpoint string   `json:"endpoint"` // default DefaultSVTEndpoint
+			Timeout  Duration `json:"timeout"`  // default 10s
+		}
+		if err := decodeSpec(spec, &c); err != nil {
+			return nil, err
+		}
+		if c.Endpoint != "" {
+			if _, err := url.Parse(c.Endpoint); err != nil {
+				return nil, fmt.Errorf("endpoint: %w", err)
+			}
+		}
+		s := NewSVTPlay(name, c.Endpoint)
+		s.Timeout = c.Timeout.or(s.Timeout)
+		return s, nil
+	})
+}
+
+// SVTPlay forwards SVT Play URLs to an HTTP endpoint (GET with ?url=), which
+// does the actual casting. It has no notion of devices.
+type SVTPlay struct {
+	name     string
+	Endpoint string
+	Timeout  time.Duration
+	// Do performs the GET and returns the response status; Get by default.
+	Do func(ctx context.Context, requestURL string) (int, error)
+}
+
+// NewSVTPlay returns an SVT Play forwarder ("" means DefaultSVTEndpoint).
+func NewSVTPlay(name, endpoint string) *SVTPlay {
+	if endpoint == "" {
+		endpoint = DefaultSVTEndpoint
+	}
+	return &SVTPlay{name: name, Endpoint: endpoint, Timeout: 10 * time.Second, Do: Get}
+}
+
+func (s *SVTPlay) Name() string { return s.name }
+
+func (s *SVTPlay) Capabilities() []Capability { return []Capability{CapPlay} }
+
+// Play forwards the URL of m to the endpoint. device is ignored.
+func (s *SVTPlay) Play(ctx context.Context, device string, m Media) error {
+	ep, err := url.Parse(s.Endpoint)
+	if err != nil {
+		slog.Error("svtplay invalid endpoint", "caster", s.name, "endpoint", s.Endpoint, "err", err)
+		return &Error{Caster: s.name, Op: CapPlay, Remote: true, Err: fmt.Errorf("invalid endpoint")}
+	}
+	q := ep.Query()
+	q.Set("url", m.URL)
+	ep.RawQuery = q.Encode()
+	reqURL := ep.String()
+	slog.Info("svtplay request", "caster", s.name, "url", reqURL)
+
+	ctx, cancel := context.WithTimeout(ctx, s.Timeout)
+	defer cancel()
+	status, err := s.Do(ctx, reqURL)
+	if err != nil {
+		slog.Error("svtplay call failed", "caster", s.name, "url", reqURL, "err", err)
+		return &Error{Caster: s.name, Op: CapPlay, Remote: true, Err: err}
+	}
+	if status < 200 || status >= 300 {
+		slog.Warn("svtplay non-2xx", "caster", s.name, "status", status, "url", reqURL)
+		return &Error{Caster: s.name, Op: CapPlay, Remote: true, Err: fmt.Errorf("endpoint status %d", status)}
+	}
+	slog.Info("svtplay forwarded", "caster", s.name, "url", reqURL)
+	return nil
+}
+
+func (s *SVTPlay) Queue(context.Context, string, Media) error { return ErrNotSupported }
+
+func (s *SVTPlay) Pair(context.Context, string) error { return ErrNotSupported }
+
+func (s *SVTPlay) ListDevices(context.Context) ([]Device, error) { return nil, ErrNotSupported }
+
+// Get issues a GET request to requestURL and returns the response status.
+func Get(ctx context.Context, requestURL string) (int, error) {
+	req, err := nethttp.NewRequestWithContext(ctx, nethttp.MethodGet, requestURL, nil)
+	if err != nil {
+		return 0, err
+	}
+	client := &nethttp.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	_ = resp.Body.Close()
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,59 @@
+package cast
+
+import (
+	"context"
+	"errors"
+	nethttp "net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSVTPlay_Play(t *testing.T) {
+	var got string
+	status := nethttp.StatusOK
+	srv := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		got = r.URL.Query().Get("url")
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	s := NewSVTPlay("svt", srv.URL+"/play?token=x")
+	if err := s.Play(context.Background(), "", Media{URL: "https://www.svtplay.se/video/abc"}); err != nil {
+		t.Fatal(err)
+	}
+	if got != "https://www.svtplay.se/video/abc" {
+		t.Fatalf("endpoint got url %q", got)
+	}
+
+	status = nethttp.StatusInternalServerError
+	err := s.Play(context.Background(), "", Media{URL: "https://www.svtplay.se/video/abc"})
+	var ce *Error
+	if !errors.As(err, &ce) || !ce.Remote || ce.Op != CapPlay {
+		t.Fatalf("expected remote play error, got %v", err)
+	}
+
+	if err := s.Queue(context.Background(), "", Media{URL: "x"}); !errors.Is(err, ErrNotSupported) {
+		t.Fatalf("Queue: expected ErrNotSupported, got %v", err)
+	}
+	if Supports(s, CapQueue) || !Supports(s, CapPlay) {
+		t.Fatalf("unexpected capabilities %v", s.Capabilities())
+	}
+}
+
+func TestYtcast_RejectsBadRequests(t *testing.T) {
+	y := NewYtcast("yt", "/nonexistent/ytcast")
+	ctx := context.Background()
+	if err := y.Play(ctx, "tv", Media{URL: "not a url"}); !errors.Is(err, ErrInvalidURL) {
+		t.Errorf("expected ErrInvalidURL, got %v", err)
+	}
+	if err := y.Queue(ctx, "tv", Media{URL: "https://vimeo.com/1"}); !errors.Is(err, ErrUnsupportedURL) {
+		t.Errorf("expected ErrUnsupportedURL, got %v", err)
+	}
+	if err := y.Play(ctx, "", Media{URL: "https://youtu.be/abc"}); !errors.Is(err, ErrNoDevice) {
+		t.Errorf("expected ErrNoDevice, got %v", err)
+	}
+	var ce *Error
+	if err := y.Play(ctx, "tv", Media{URL: "https://youtu.be/abc"}); !errors.As(err, &ce) || ce.Remote {
+		t.Errorf("expected local *Error for missing binary, got %v", err)
+	}
+}
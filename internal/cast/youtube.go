@@ -0,0 +1,395 @@
+package cast
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/claes/ytplv/internal/lounge"
+	"github.com/claes/ytplv/internal/source"
+)
+
+func init() {
+	RegisterType("youtube", func(name string, spec json.RawMessage) (Caster, error) {
+		var c struct {
+			Name    string   `json:"name"`
+			Type    string   `json:"type"`
+			Remote  string   `json:"remote"`  // name shown on the TV; default "castweb"
+			DIAL    *bool    `json:"dial"`    // look for screens on the local network; default true
+			Timeout Duration `json:"timeout"` // per call; default DefaultTimeout
+		}
+		if err := decodeSpec(spec, &c); err != nil {
+			return nil, err
+		}
+		y := NewYouTube(name)
+		if c.Remote != "" {
+			y.Client.Name = c.Remote
+		}
+		if c.DIAL != nil {
+			y.DIAL = *c.DIAL
+		}
+		y.Timeout = c.Timeout.or(y.Timeout)
+		return y, nil
+	})
+}
+
+// YouTube casts YouTube videos to TVs running the YouTube app, speaking the
+// lounge API itself. Devices are paired screens, given by screen ID or
+// (case-insensitive) name; paired screens and their lounge tokens are kept
+// in the StateStore. With DIAL set, screens on the local network whose
+// YouTube app is open can be used without pairing.
+type YouTube struct {
+	name     string
+	Client   *lounge.Client
+	DIAL     bool
+	DIALWait time.Duration // how long discovery listens for answers
+	DIALTTL  time.Duration // how long devices are looked up in the last discovery
+	Timeout  time.Duration
+
+	saving      sync.Mutex // serialises saves to the StateStore
+	discovering sync.Mutex // serialises DIAL discoveries
+
+	mu       sync.Mutex // guards the fields below, never held across network calls or I/O
+	screens  []lounge.Screen
+	sessions map[string]*lounge.Session // by screen ID
+	busy     map[string]*sync.Mutex     // by screen ID; serialises connecting and sending
+	dialed   []lounge.Screen            // found by the last discovery
+	dialedAt time.Time
+	store    StateStore
+}
+
+// youtubeState is what YouTube keeps in its StateStore.
+type youtubeState struct {
+	Screens []lounge.Screen `json:"screens"`
+}
+
+// NewYouTube returns a YouTube caster talking to YouTube's lounge API.
+func NewYouTube(name string) *YouTube {
+	return &YouTube{
+		name:     name,
+		Client:   lounge.NewClient("castweb"),
+		DIAL:     true,
+		DIALWait: 2 * time.Second,
+		DIALTTL:  30 * time.Second,
+		Timeout:  DefaultTimeout,
+		sessions: make(map[string]*lounge.Session),
+		busy:     make(map[string]*sync.Mutex),
+	}
+}
+
+func (y *YouTube) Name() string { return y.name }
+
+func (y *YouTube) Capabilities() []Capability {
+	return []Capability{CapPlay, CapQueue, CapPair, CapList, CapStop}
+}
+
+// SetStateStore loads the paired screens from st and saves them there from
+// now on.
+func (y *YouTube) SetStateStore(st StateStore) {
+	var state youtubeState
+	err := st.LoadCasterState(y.name, &state)
+	y.mu.Lock()
+	defer y.mu.Unlock()
+	y.store = st
+	if err != nil {
+		slog.Warn("youtube state load failed", "caster", y.name, "err", err)
+		return
+	}
+	y.screens = state.Screens
+}
+
+// Play replaces what plays on device with the video of m.
+func (y *YouTube) Play(ctx context.Context, device string, m Media) error {
+	id, start, err := youtubeVideo(m.URL)
+	if err != nil {
+		return err
+	}
+	slog.Info("youtube play", "caster", y.name, "device", device, "video", id, "start", start)
+	return y.send(ctx, CapPlay, device, func(sess *lounge.Session) error {
+		return sess.SetPlaylist(ctx, id, start)
+	})
+}
+
+// Queue adds the video of m to the queue of device.
+func (y *YouTube) Queue(ctx context.Context, device string, m Media) error {
+	id, _, err := youtubeVideo(m.URL)
+	if err != nil {
+		return err
+	}
+	slog.Info("youtube queue", "caster", y.name, "device", device, "video", id)
+	return y.send(ctx, CapQueue, device, func(sess *lounge.Session) error {
+		return sess.AddVideo(ctx, id)
+	})
+}
+
+// Stop stops playback on device.
+func (y *YouTube) Stop(ctx context.Context, device string) error {
+	return y.send(ctx, CapStop, device, func(sess *lounge.Session) error {
+		return sess.StopVideo(ctx)
+	})
+}
+
+func (y *YouTube) PlayPause(context.Context, string) error { return ErrNotSupported }
+
+// Pair pairs with the screen showing code and remembers it.
+func (y *YouTube) Pair(ctx context.Context, code string) error {
+	ctx, cancel := context.WithTimeout(ctx, y.Timeout)
+	defer cancel()
+	s, err := y.Client.Pair(ctx, code)
+	if errors.Is(err, lounge.ErrInvalidCode) {
+		slog.Warn("youtube pairing code not found", "caster", y.name, "code", code)
+		return ErrInvalidCode
+	}
+	if err != nil {
+		return y.fail(CapPair, err)
+	}
+	y.remember(s)
+	slog.Info("youtube paired", "caster", y.name, "screen", s.ID, "name", s.Name)
+	return nil
+}
+
+// ListDevices returns the paired screens and, with DIAL, the screens found
+// on the local network.
+func (y *YouTube) ListDevices(ctx context.Context) ([]Device, error) {
+	y.mu.Lock()
+	screens := append([]lounge.Screen(nil), y.screens...)
+	y.mu.Unlock()
+	if y.DIAL {
+		y.discovering.Lock()
+		found := y.discover(ctx)
+		y.discovering.Unlock()
+		for _, s := range found {
+			if !hasScreen(screens, s.ID) {
+				screens = append(screens, s)
+			}
+		}
+	}
+	devs := make([]Device, 0, len(screens))
+	for _, s := range screens {
+		devs = append(devs, Device{ID: s.ID, Name: s.Name})
+	}
+	return devs, nil
+}
+
+// send runs fn on a session with device, reconnecting once when the session
+// ended or its lounge token was rejected. Calls to one screen run one at a
+// time; calls to different screens do not wait for each other.
+func (y *YouTube) send(ctx context.Context, op Capability, device string, fn func(*lounge.Session) error) error {
+	if device == "" {
+		return ErrNoDevice
+	}
+	ctx, cancel := context.WithTimeout(ctx, y.Timeout)
+	defer cancel()
+	s, err := y.resolve(ctx, device)
+	if err != nil {
+		return err
+	}
+	y.mu.Lock()
+	busy := y.busy[s.ID]
+	if busy == nil {
+		busy = new(sync.Mutex)
+		y.busy[s.ID] = busy
+	}
+	y.mu.Unlock()
+	busy.Lock()
+	defer busy.Unlock()
+
+	for attempt := 0; ; attempt++ {
+		sess, err := y.session(ctx, op, s)
+		if err != nil {
+			return err
+		}
+		err = fn(sess)
+		if err == nil {
+			return nil
+		}
+		stale := errors.Is(err, lounge.ErrSessionGone) || errors.Is(err, lounge.ErrUnauthorized)
+		if !stale || attempt > 0 {
+			return y.fail(op, err)
+		}
+		slog.Info("youtube reconnecting", "caster", y.name, "screen", s.ID, "err", err)
+		y.mu.Lock()
+		delete(y.sessions, s.ID)
+		y.mu.Unlock()
+		if errors.Is(err, lounge.ErrUnauthorized) {
+			stored := sess.Screen()
+			stored.Token = ""
+			y.remember(stored)
+		}
+	}
+}
+
+// resolve returns the known screen with ID or name device, else (with DIAL)
+// a matching screen on the local network. Discoveries are reused for
+// DIALTTL, so that unknown names do not wait for one each time.
+func (y *YouTube) resolve(ctx context.Context, device string) (lounge.Screen, error) {
+	y.mu.Lock()
+	s, ok := y.lookup(device)
+	y.mu.Unlock()
+	if ok {
+		return s, nil
+	}
+	if y.DIAL {
+		for _, d := range y.discovered(ctx) {
+			if d.ID == device || strings.EqualFold(d.Name, device) {
+				return d, nil
+			}
+		}
+	}
+	return lounge.Screen{}, fmt.Errorf("%w: no paired screen %q", ErrNoDevice, device)
+}
+
+// session returns the session with screen s, connecting (and fetching a
+// lounge token) if needed. The caller serialises calls per screen.
+func (y *YouTube) session(ctx context.Context, op Capability, s lounge.Screen) (*lounge.Session, error) {
+	y.mu.Lock()
+	sess := y.sessions[s.ID]
+	for _, stored := range y.screens {
+		if stored.ID == s.ID {
+			s = stored // pick up tokens refreshed since resolve
+		}
+	}
+	y.mu.Unlock()
+	if sess != nil {
+		return sess, nil
+	}
+	for attempt := 0; ; attempt++ {
+		if !s.Fresh(time.Now()) {
+			fresh, err := y.Client.Refresh(ctx, s)
+			if err != nil {
+				return nil, y.fail(op, err)
+			}
+			s = fresh
+			y.remember(s)
+		}
+		sess, err := y.Client.Connect(ctx, s)
+		if errors.Is(err, lounge.ErrUnauthorized) && attempt == 0 {
+			s.Token = ""
+			continue
+		}
+		if err != nil {
+			return nil, y.fail(op, err)
+		}
+		y.mu.Lock()
+		y.sessions[s.ID] = sess
+		y.mu.Unlock()
+		return sess, nil
+	}
+}
+
+// lookup returns the known screen with ID or name device; y.mu must be held.
+func (y *YouTube) lookup(device string) (lounge.Screen, bool) {
+	for _, s := range y.screens {
+		if s.ID == device {
+			return s, true
+		}
+	}
+	for _, s := range y.screens {
+		if strings.EqualFold(s.Name, device) {
+			return s, true
+		}
+	}
+	return lounge.Screen{}, false
+}
+
+// remember adds or updates s in the known screens and saves them.
+func (y *YouTube) remember(s lounge.Screen) {
+	y.mu.Lock()
+	found := false
+	for i := range y.screens {
+		if y.screens[i].ID == s.ID {
+			y.screens[i] = s
+			found = true
+		}
+	}
+	if !found {
+		y.screens = append(y.screens, s)
+	}
+	y.mu.Unlock()
+	y.save()
+}
+
+// save writes the known screens to the StateStore. Saves run one at a time
+// and copy the screens when they start, so the last one written is current.
+func (y *YouTube) save() {
+	y.saving.Lock()
+	defer y.saving.Unlock()
+	y.mu.Lock()
+	st := y.store
+	state := youtubeState{Screens: append([]lounge.Screen(nil), y.screens...)}
+	y.mu.Unlock()
+	if st == nil {
+		return
+	}
+	if err := st.SaveCasterState(y.name, state); err != nil {
+		slog.Error("youtube state save failed", "caster", y.name, "err", err)
+	}
+}
+
+// discovered returns the screens of the last discovery, discovering again
+// when it is older than DIALTTL.
+func (y *YouTube) discovered(ctx context.Context) []lounge.Screen {
+	y.discovering.Lock()
+	defer y.discovering.Unlock()
+	y.mu.Lock()
+	screens, at := y.dialed, y.dialedAt
+	y.mu.Unlock()
+	if !at.IsZero() && time.Since(at) < y.DIALTTL {
+		return screens
+	}
+	return y.discover(ctx)
+}
+
+// discover returns the screens found with DIAL, skipping devices without a
+// running YouTube app, and keeps them for discovered; y.discovering must be
+// held.
+func (y *YouTube) discover(ctx context.Context) []lounge.Screen {
+	locations, err := lounge.Discover(ctx, y.DIALWait)
+	if err != nil {
+		slog.Warn("youtube discovery failed", "caster", y.name, "err", err)
+	}
+	var screens []lounge.Screen
+	for _, loc := range locations {
+		s, err := y.Client.DIALScreen(ctx, loc)
+		if err != nil {
+			slog.Debug("youtube skipping DIAL device", "caster", y.name, "location", loc, "err", err)
+			continue
+		}
+		screens = append(screens, s)
+	}
+	y.mu.Lock()
+	y.dialed, y.dialedAt = screens, time.Now()
+	y.mu.Unlock()
+	return screens
+}
+
+// fail logs err and wraps it in an *Error.
+func (y *YouTube) fail(op Capability, err error) error {
+	slog.Error("youtube call failed", "caster", y.name, "op", op, "err", err)
+	return &Error{Caster: y.name, Op: op, Remote: true, Err: err}
+}
+
+func hasScreen(screens []lounge.Screen, id string) bool {
+	for _, s := range screens {
+		if s.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// youtubeVideo returns the video ID and start offset of a YouTube URL.
+func youtubeVideo(u string) (id string, start int, err error) {
+	if err := checkYouTubeURL(u); err != nil {
+		return "", 0, err
+	}
+	if typ, id := source.Parse(u); typ == source.YouTube.Name() && id != "" {
+		return id, source.StartOffset(u), nil
+	}
+	return "", 0, fmt.Errorf("%w: not a video: %s", ErrUnsupportedURL, u)
+}
@@ -0,0 +1,226 @@
+package cast
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/claes/ytplv/internal/lounge"
+	"github.com/claes/ytplv/internal/lounge/loungetest"
+)
+
+// memStore is a StateStore in memory.
+type memStore map[string]json.RawMessage
+
+func (m memStore) LoadCasterState(caster string, v any) error {
+	if b, ok := m[caster]; ok {
+		return json.Unmarshal(b, v)
+	}
+	return nil
+}
+
+func (m memStore) SaveCasterState(caster string, v any) error {
+	b, err := json.Marshal(v)
+	m[caster] = b
+	return err
+}
+
+func newTestYouTube(fake *loungetest.Server, st StateStore) *YouTube {
+	y := NewYouTube("yt")
+	y.Client.BaseURL = fake.URL
+	y.DIAL = false
+	y.SetStateStore(st)
+	return y
+}
+
+func TestYouTube_PairPlayQueueStop(t *testing.T) {
+	fake := loungetest.NewServer()
+	defer fake.Close()
+	fake.AddScreen("123456789012", "scr1", "Living Room")
+	st := memStore{}
+	y := newTestYouTube(fake, st)
+	ctx := context.Background()
+
+	if err := y.Play(ctx, "living room", Media{URL: "https://youtu.be/abc"}); !errors.Is(err, ErrNoDevice) {
+		t.Fatalf("play before pairing: got %v, want ErrNoDevice", err)
+	}
+	if err := y.Pair(ctx, "999999999999"); !errors.Is(err, ErrInvalidCode) {
+		t.Fatalf("pair with unknown code: got %v, want ErrInvalidCode", err)
+	}
+	if err := y.Pair(ctx, "123456789012"); err != nil {
+		t.Fatal(err)
+	}
+	if err := y.Play(ctx, "living room", Media{URL: "https://www.youtube.com/watch?v=abc&t=90"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := y.Queue(ctx, "scr1", Media{URL: "https://youtu.be/def"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := y.Stop(ctx, "scr1"); err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, c := range fake.Commands() {
+		got = append(got, c.Name+" "+c.Screen+" "+c.Params.Get("videoId")+" "+c.Params.Get("currentTime"))
+	}
+	want := []string{"setPlaylist scr1 abc 90", "addVideo scr1 def ", "stopVideo scr1  "}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("commands = %q, want %q", got, want)
+	}
+
+	devs, err := y.ListDevices(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(devs, []Device{{ID: "scr1", Name: "Living Room"}}) {
+		t.Fatalf("devices = %+v", devs)
+	}
+
+	// a new caster over the same state knows the screen
+	y2 := newTestYouTube(fake, st)
+	if err := y2.Play(ctx, "scr1", Media{URL: "https://youtu.be/ghi"}); err != nil {
+		t.Fatalf("play after reload: %v", err)
+	}
+}
+
+func TestYouTube_Reconnects(t *testing.T) {
+	fake := loungetest.NewServer()
+	defer fake.Close()
+	fake.AddScreen("123456789012", "scr1", "TV")
+	y := newTestYouTube(fake, memStore{})
+	ctx := context.Background()
+	if err := y.Pair(ctx, "123456789012"); err != nil {
+		t.Fatal(err)
+	}
+	m := Media{URL: "https://youtu.be/abc"}
+	if err := y.Play(ctx, "TV", m); err != nil {
+		t.Fatal(err)
+	}
+	fake.ExpireSessions()
+	if err := y.Queue(ctx, "TV", m); err != nil {
+		t.Fatalf("queue after the session ended: %v", err)
+	}
+	fake.ExpireTokens()
+	fake.ExpireSessions()
+	if err := y.Queue(ctx, "TV", m); err != nil {
+		t.Fatalf("queue after the token expired: %v", err)
+	}
+	if n := len(fake.Commands()); n != 3 {
+		t.Fatalf("expected 3 commands, got %d", n)
+	}
+
+	fake.Close()
+	var ce *Error
+	if err := y.Play(ctx, "TV", m); !errors.As(err, &ce) || !ce.Remote || ce.Op != CapPlay {
+		t.Fatalf("expected remote *Error when the API is down, got %v", err)
+	}
+}
+
+func TestYouTube_ScreensDoNotWaitForEachOther(t *testing.T) {
+	fake := loungetest.NewServer()
+	defer fake.Close()
+	fake.AddScreen("111111111111", "scr1", "Slow")
+	fake.AddScreen("222222222222", "scr2", "Fast")
+	y := newTestYouTube(fake, memStore{})
+	ctx := context.Background()
+	for _, code := range []string{"111111111111", "222222222222"} {
+		if err := y.Pair(ctx, code); err != nil {
+			t.Fatal(err)
+		}
+	}
+	release := fake.Hold("scr1")
+	slow := make(chan error, 1)
+	go func() { slow <- y.Play(ctx, "Slow", Media{URL: "https://youtu.be/abc"}) }()
+
+	fast := make(chan error, 1)
+	go func() { fast <- y.Play(ctx, "Fast", Media{URL: "https://youtu.be/def"}) }()
+	select {
+	case err := <-fast:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("play to one screen waited for another")
+	}
+	if err := y.Pair(ctx, "222222222222"); err != nil {
+		t.Fatalf("pair while a screen is busy: %v", err)
+	}
+	release()
+	if err := <-slow; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestYouTube_RejectsURLs(t *testing.T) {
+	y := NewYouTube("yt")
+	y.DIAL = false
+	ctx := context.Background()
+	for u, want := range map[string]error{
+		"not a url":           ErrInvalidURL,
+		"https://vimeo.com/1": ErrUnsupportedURL,
+		"https://www.youtube.com/playlist?list=PLx": ErrUnsupportedURL,
+	} {
+		if err := y.Play(ctx, "tv", Media{URL: u}); !errors.Is(err, want) {
+			t.Errorf("%s: got %v, want %v", u, err, want)
+		}
+	}
+	if err := y.Play(ctx, "", Media{URL: "https://youtu.be/abc"}); !errors.Is(err, ErrNoDevice) {
+		t.Errorf("empty device: got %v", err)
+	}
+}
+
+// unlockedStore is a memStore failing the test when saved to with y.mu held.
+type unlockedStore struct {
+	memStore
+	t *testing.T
+	y *YouTube
+}
+
+func (u *unlockedStore) SaveCasterState(caster string, v any) error {
+	if !u.y.mu.TryLock() {
+		u.t.Error("state saved with y.mu held")
+	} else {
+		u.y.mu.Unlock()
+	}
+	return u.memStore.SaveCasterState(caster, v)
+}
+
+func TestYouTube_SavesStateUnlocked(t *testing.T) {
+	fake := loungetest.NewServer()
+	defer fake.Close()
+	fake.AddScreen("111122223333", "screen-1", "Living Room")
+	st := &unlockedStore{memStore: memStore{}, t: t}
+	y := newTestYouTube(fake, st)
+	st.y = y
+	ctx := context.Background()
+	if err := y.Pair(ctx, "111122223333"); err != nil {
+		t.Fatal(err)
+	}
+	fake.ExpireTokens()
+	if err := y.Play(ctx, "Living Room", Media{URL: "https://youtu.be/abc"}); err != nil {
+		t.Fatal(err)
+	}
+	if len(st.memStore["yt"]) == 0 {
+		t.Fatal("state not saved")
+	}
+}
+
+func TestYouTube_ReusesDiscovery(t *testing.T) {
+	y := NewYouTube("yt")
+	y.DIALWait = time.Minute // a discovery would outlast the test
+	y.dialed = []lounge.Screen{{ID: "dial-1", Name: "Den"}}
+	y.dialedAt = time.Now()
+	if s, err := y.resolve(context.Background(), "den"); err != nil || s.ID != "dial-1" {
+		t.Fatalf("resolve den: %+v, %v", s, err)
+	}
+	start := time.Now()
+	if _, err := y.resolve(context.Background(), "nowhere"); !errors.Is(err, ErrNoDevice) {
+		t.Fatalf("expected ErrNoDevice, got %v", err)
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Fatalf("unknown name took %v", d)
+	}
+}
@@ -0,0 +1,82 @@
+package cast
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// DefaultTimeout bounds a single backend call unless configured otherwise.
+const DefaultTimeout = 15 * time.Second
+
+func init() {
+	RegisterType("ytcast", func(name string, spec json.RawMessage) (Caster, error) {
+		var c struct {
+			Name    string   `json:"name"`
+			Type    string   `json:"type"`
+			Bin     string   `json:"bin"`     // ytcast executable; default "ytcast" from PATH
+			Timeout Duration `json:"timeout"` // per call; default DefaultTimeout
+		}
+		if err := decodeSpec(spec, &c); err != nil {
+			return nil, err
+		}
+		y := NewYtcast(name, c.Bin)
+		y.Timeout = c.Timeout.or(DefaultTimeout)
+		return y, nil
+	})
+}
+
+// Ytcast casts YouTube URLs by running the ytcast command. It is a Command
+// with fixed templates that also checks URLs before running anything.
+type Ytcast struct {
+	*Command
+}
+
+// NewYtcast returns a ytcast caster running bin ("" means "ytcast" from PATH).
+func NewYtcast(name, bin string) *Ytcast {
+	if bin == "" {
+		bin = "ytcast"
+	}
+	c, err := NewCommand(name, CommandConfig{
+		Play:  []string{bin, "-d", "{{.Device}}", "{{.URL}}"},
+		Queue: []string{bin, "-d", "{{.Device}}", "-a", "{{.URL}}"},
+		Pair:  []string{bin, "-pair", "{{.Code}}"},
+		List:  []string{bin, "-l"},
+	})
+	if err != nil {
+		panic(err) // the templates are fixed
+	}
+	return &Ytcast{Command: c}
+}
+
+// Play runs `ytcast -d <device> <url>`.
+func (y *Ytcast) Play(ctx context.Context, device string, m Media) error {
+	if err := checkYouTubeURL(m.URL); err != nil {
+		return err
+	}
+	return y.Command.Play(ctx, device, m)
+}
+
+// Queue runs `ytcast -d <device> -a <url>`.
+func (y *Ytcast) Queue(ctx context.Context, device string, m Media) error {
+	if err := checkYouTubeURL(m.URL); err != nil {
+		return err
+	}
+	return y.Command.Queue(ctx, device, m)
+}
+
+// checkYouTubeURL rejects anything but absolute youtube.com/youtu.be URLs.
+func checkYouTubeURL(u string) error {
+	parsed, err := url.Parse(u)
+	if err != nil || parsed.Scheme == "" || parsed.Host == "" {
+		return ErrInvalidURL
+	}
+	host := strings.ToLower(parsed.Host)
+	if !strings.HasSuffix(host, "youtube.com") && !strings.HasSuffix(host, "youtu.be") {
+		return fmt.Errorf("%w: %s", ErrUnsupportedURL, host)
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package http
+
+import (
+	"log/slog"
+	"maps"
+	nethttp "net/http"
+	"path/filepath"
+	"time"
+
+	"github.com/claes/ytplv/internal/model"
+	"github.com/claes/ytplv/internal/source"
+	"github.com/claes/ytplv/internal/store"
+)
+
+// activityRetention is how long play and queue activity is remembered.
+const activityRetention = 180 * 24 * time.Hour
+
+// castDone answers a successful /play or /queue request and records it for
+// the "Continue watching" folder.
+func (s *server) castDone(w nethttp.ResponseWriter, r *nethttp.Request, queued bool) {
+	key := activityKey(r.FormValue("type"), r.FormValue("id"), r.FormValue("url"))
+	now := time.Now()
+	s.mu.Lock()
+	if s.activity == nil {
+		s.activity = make(map[string]store.Activity)
+	}
+	a := s.activity[key]
+	if queued {
+		a.Queued = now
+	} else {
+		a.Played = now
+	}
+	s.activity[key] = a
+	for k, old := range s.activity {
+		if now.Sub(old.Last()) > activityRetention {
+			delete(s.activity, k)
+		}
+	}
+	s.mu.Unlock()
+	if queued {
+		s.saveState("/queue")
+	} else {
+		s.saveState("/play")
+	}
+	w.WriteHeader(nethttp.StatusNoContent)
+}
+
+// activityKey identifies an item across requests and library scans: its
+// source type and id, or its play URL when it has no id (.url items). The
+// browse page posts exactly these values, see videoActivityKey.
+func activityKey(typ, id, rawURL string) string {
+	if id != "" {
+		return typ + ":" + id
+	}
+	return rawURL
+}
+
+// videoActivityKey is activityKey for a library item.
+func videoActivityKey(v *model.Video) string {
+	if v.VideoID != "" {
+		return activityKey(v.Type, v.VideoID, "")
+	}
+	_, u := source.Resolve(v.Type, v.VideoID, v.URL)
+	return activityKey("", "", source.WithStart(u, v.Start))
+}
+
+// activityFor returns the recorded activity of v.
+func (s *server) activityFor(v *model.Video) (store.Activity, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	a, ok := s.activity[videoActivityKey(v)]
+	return a, ok
+}
+
+// saveState persists the ytcast code, activity and caster state, if a state
+// directory is configured. route prefixes the log messages.
+func (s *server) saveState(route string) {
+	if s.stateDir == "" {
+		return
+	}
+	s.mu.RLock()
+	st := store.State{YtcastCode: s.ytcastCode, Activity: make(map[string]store.Activity, len(s.activity))}
+	for k, a := range s.activity {
+		st.Activity[k] = a
+	}
+	if len(s.casterState) > 0 {
+		st.Casters = maps.Clone(s.casterState)
+	}
+	s.mu.RUnlock()
+	statePath := filepath.Join(s.stateDir, "state.json")
+	s.saveMu.Lock()
+	defer s.saveMu.Unlock()
+	if err := store.SaveState(statePath, st); err != nil {
+		slog.Error(route+" persist failed", "err", err)
+	} else {
+		slog.Info(route+" persisted", "path", statePath)
+	}
+}
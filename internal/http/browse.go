@@ -1,67 +1,92 @@
 package http
 
 import (
-	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"html/template"
 	"log/slog"
 	nethttp "net/http"
-	"net/url"
-	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
-	"time"
 
 	"github.com/claes/ytplv/internal/browse"
+	"github.com/claes/ytplv/internal/cast"
+	"github.com/claes/ytplv/internal/library"
+	"github.com/claes/ytplv/internal/source"
 	"github.com/claes/ytplv/internal/store"
 	"sync"
 )
 
 type server struct {
 	root         string
+	lib          *library.Index
 	tpl          *template.Template
 	pairTpl      *template.Template
 	ytcastDevice string
 	ytcastCode   string
 	stateDir     string
-	svtEndpoint  string
+	casters      *cast.Router
+	recentDays   int
 	mu           sync.RWMutex
+	activity     map[string]store.Activity  // guarded by mu
+	casterState  map[string]json.RawMessage // guarded by mu; see LoadCasterState
+	saveMu       sync.Mutex                 // serialises state.json writes
 }
 
-const execTimeout = 15 * time.Second
-
-// svtDoRequest is used by handlePlay to forward SVT URLs to an external endpoint.
-// It is declared as a variable to allow tests to stub it out without network access.
-var svtDoRequest = func(ctx context.Context, requestURL string) (int, error) {
-	req, err := nethttp.NewRequestWithContext(ctx, nethttp.MethodGet, requestURL, nil)
-	if err != nil {
-		return 0, err
-	}
-	client := &nethttp.Client{Timeout: 10 * time.Second}
-	resp, err := client.Do(req)
-	if err != nil {
-		return 0, err
-	}
-	_ = resp.Body.Close()
-	return resp.StatusCode, nil
+// Config configures the HTTP handler returned by New.
+type Config struct {
+	Root         string // library root directory
+	YtcastDevice string // default ytcast device id
+	StateDir     string // directory holding state.json; "" disables persistence
+	SVTEndpoint  string // endpoint to forward SVT URLs to
+	RecentDays   int    // window of the home folders; 0 means DefaultRecentDays
+	// Casters picks the backend of each play/queue request; nil means
+	// the built-in YouTube lounge client for YouTube and the SVT forwarder at
+	// SVTEndpoint for SVT Play.
+	Casters *cast.Router
+	// Index serves listings; nil means an unwatched index over Root.
+	Index *library.Index
 }
 
 // NewServer creates an HTTP handler for browsing video metadata rooted at dir.
 func NewServer(root string, ytcastDevice string, stateDir string, svtEndpoint string) nethttp.Handler {
+	return New(Config{Root: root, YtcastDevice: ytcastDevice, StateDir: stateDir, SVTEndpoint: svtEndpoint})
+}
+
+// New creates the HTTP handler described by cfg.
+func New(cfg Config) nethttp.Handler {
 	tpl := newBrowseTemplate()
 	pairTpl := newPairTemplate()
-	s := &server{root: root, tpl: tpl, pairTpl: pairTpl, ytcastDevice: ytcastDevice, stateDir: stateDir, svtEndpoint: svtEndpoint}
+	lib := cfg.Index
+	if lib == nil {
+		lib = library.NewIndex(cfg.Root)
+	}
+	s := &server{root: cfg.Root, lib: lib, tpl: tpl, pairTpl: pairTpl, ytcastDevice: cfg.YtcastDevice, stateDir: cfg.StateDir, casters: cfg.Casters, recentDays: cfg.RecentDays}
+	if s.casters == nil {
+		s.casters = defaultCasters(cfg.SVTEndpoint)
+	}
+	if s.recentDays <= 0 {
+		s.recentDays = DefaultRecentDays
+	}
 	// Load state if present; do not create directories/files here (packaging/systemd owns it).
-	if stateDir != "" {
-		statePath := filepath.Join(stateDir, "state.json")
+	if s.stateDir != "" {
+		statePath := filepath.Join(s.stateDir, "state.json")
 		if st, err := store.LoadState(statePath); err != nil {
 			slog.Warn("state load failed", "path", statePath, "err", err)
-		} else if st.YtcastCode != "" {
+		} else if st.YtcastCode != "" || len(st.Activity) > 0 || len(st.Casters) > 0 {
 			s.ytcastCode = st.YtcastCode
+			s.activity = st.Activity
+			s.casterState = st.Casters
 			slog.Info("state loaded", "path", statePath)
 		}
 	}
+	for _, c := range s.casters.Casters() {
+		if sc, ok := c.(cast.Stateful); ok {
+			sc.SetStateStore(s)
+		}
+	}
 	mux := nethttp.NewServeMux()
 	mux.HandleFunc("/", s.handleBrowse)
 	mux.HandleFunc("/pair", func(w nethttp.ResponseWriter, r *nethttp.Request) {
@@ -75,11 +100,19 @@ func NewServer(root string, ytcastDevice string, stateDir string, svtEndpoint st
 	mux.HandleFunc("/health", func(w nethttp.ResponseWriter, r *nethttp.Request) {
 		HealthHandler().ServeHTTP(w, r)
 	})
+	mux.HandleFunc("/search", s.handleSearch)
+	mux.HandleFunc("/"+tagsPath+"/", s.handleTags)
+	for _, hf := range s.homeFolders() {
+		mux.HandleFunc("/"+hf.path+"/", s.handleHome(hf))
+	}
 	mux.HandleFunc("/play", s.handlePlay)
 	mux.HandleFunc("/queue", s.handleQueue)
+	mux.HandleFunc("/stop", s.handleControl("/stop", cast.CapStop))
+	mux.HandleFunc("/pause", s.handleControl("/pause", cast.CapPause))
 	mux.HandleFunc("/ytcast/pair", s.handleYtcastPair)
 	mux.HandleFunc("/ytcast/set-code", s.handleYtcastSetCode)
 	mux.HandleFunc("/ytcast/list", s.handleYtcastList)
+	mux.HandleFunc("/api/diagnostics", s.handleDiagnostics)
 	return mux
 }
 
@@ -88,49 +121,186 @@ func (s *server) handlePlay(w nethttp.ResponseWriter, r *nethttp.Request) {
 	if !ok {
 		return
 	}
-	ctx := r.Context()
-	switch typ {
-	case "svtplay":
-		if code, err := s.playSVT(ctx, u); err != nil {
-			httpError(w, code, err.Error())
-			return
-		}
-		w.WriteHeader(nethttp.StatusNoContent)
+	if !requireAction(w, typ, source.ActionPlay) || s.dirIgnored(w, r, "/play") {
+		return
+	}
+	device := s.deviceFor(r)
+	c := s.casterFor(w, typ, device, cast.CapPlay)
+	if c == nil {
 		return
-	default:
-		if code, err := s.playYouTube(ctx, u); err != nil {
-			httpError(w, code, err.Error())
+	}
+	var err error
+	if typ == source.YouTubePlaylist.Name() {
+		urls, ok := playlistURLs(w, r.FormValue("ids"))
+		if !ok {
 			return
 		}
-		w.WriteHeader(nethttp.StatusNoContent)
+		err = castPlaylist(r.Context(), c, device, urls, false)
+	} else {
+		m := s.mediaFor(r, typ, u)
+		slog.Info("/play casting", "caster", c.Name(), "device", device, "url", u)
+		err = c.Play(r.Context(), device, m)
+	}
+	if err != nil {
+		castError(w, "/play", c, err, "failed to cast")
 		return
 	}
+	s.castDone(w, r, false)
 }
 
-// handleQueue queues a YouTube URL on the configured device (ytcast -a).
-// Only YouTube URLs are supported; SVT is not applicable.
+// handleQueue adds a URL to the queue of the device. Only sources declaring
+// the queue action (YouTube) are accepted, and only when their caster can.
 func (s *server) handleQueue(w nethttp.ResponseWriter, r *nethttp.Request) {
 	typ, u, ok := parsePlayParams(w, r)
 	if !ok {
 		return
 	}
-	ctx := r.Context()
-	// Only allow YouTube for queuing
-	switch typ {
-	case "", "youtube":
-		if code, err := s.queueYouTube(ctx, u); err != nil {
-			httpError(w, code, err.Error())
+	if !requireAction(w, typ, source.ActionQueue) || s.dirIgnored(w, r, "/queue") {
+		return
+	}
+	device := s.deviceFor(r)
+	c := s.casterFor(w, typ, device, cast.CapQueue)
+	if c == nil {
+		return
+	}
+	var err error
+	if typ == source.YouTubePlaylist.Name() {
+		urls, ok := playlistURLs(w, r.FormValue("ids"))
+		if !ok {
 			return
 		}
-		w.WriteHeader(nethttp.StatusNoContent)
-		return
-	default:
-		httpError(w, nethttp.StatusBadRequest, "queue supported only for youtube")
+		err = castPlaylist(r.Context(), c, device, urls, true)
+	} else {
+		m := s.mediaFor(r, typ, u)
+		slog.Info("/queue casting", "caster", c.Name(), "device", device, "url", u)
+		err = c.Queue(r.Context(), device, m)
+	}
+	if err != nil {
+		castError(w, "/queue", c, err, "failed to cast")
 		return
 	}
+	s.castDone(w, r, true)
+}
+
+// dirIgnored writes a 404 error and returns true when the item's folder, the
+// "dir" form value, is excluded by a .castwebignore.
+func (s *server) dirIgnored(w nethttp.ResponseWriter, r *nethttp.Request, route string) bool {
+	dir := r.FormValue("dir")
+	if dir == "" || !browse.IgnoredPath(s.root, dir, true) {
+		return false
+	}
+	slog.Warn(route+" ignored folder", "dir", dir)
+	httpError(w, nethttp.StatusNotFound, "unable to read path")
+	return true
+}
+
+// mediaFor returns what to cast for the item of type typ at u. Its .strm
+// line is looked up in the item's folder (the "dir" form value) rather than
+// taken from the client, so that a caster such as Kodi only opens lines
+// written in the library.
+func (s *server) mediaFor(r *nethttp.Request, typ, u string) cast.Media {
+	m := cast.Media{URL: u}
+	dir := r.FormValue("dir")
+	_, id := source.Parse(u)
+	if id == "" || !browse.IsSubpath(s.root, filepath.Join(s.root, dir)) {
+		return m
+	}
+	listing, err := s.lib.Listing(dir)
+	if err != nil {
+		return m
+	}
+	for _, v := range listing.Videos {
+		if v.STRM == "" || v.Type != typ {
+			continue
+		}
+		if strmType, strmID := source.Parse(v.STRM); strmType == typ && strmID == id {
+			m.STRM = v.STRM
+			break
+		}
+	}
+	return m
+}
+
+// playlistURLs turns the comma-separated video ids of a playlist into
+// watch URLs. Writes a 400 error and returns ok=false when there are none or
+// one is invalid.
+func playlistURLs(w nethttp.ResponseWriter, ids string) (urls []string, ok bool) {
+	for _, id := range strings.Split(ids, ",") {
+		id = strings.TrimSpace(id)
+		if id == "" {
+			continue
+		}
+		if !isVideoID(id) {
+			slog.Warn("/play playlist invalid video id", "id", id)
+			httpError(w, nethttp.StatusBadRequest, "invalid video id")
+			return nil, false
+		}
+		urls = append(urls, source.YouTube.PlayURL(id))
+	}
+	if len(urls) == 0 {
+		slog.Warn("/play playlist without video ids", "hint", "add video_ids to the STRM or a .playlist sidecar")
+		httpError(w, nethttp.StatusBadRequest, "playlist has no known videos")
+		return nil, false
+	}
+	return urls, true
+}
+
+// castPlaylist casts the videos of a playlist. The first video is played and
+// the rest queued; with queueOnly every video is queued. A caster that cannot
+// queue only plays the first video.
+func castPlaylist(ctx context.Context, c cast.Caster, device string, urls []string, queueOnly bool) error {
+	if !queueOnly && !cast.Supports(c, cast.CapQueue) && len(urls) > 1 {
+		slog.Warn("/play playlist caster cannot queue, playing first video only", "caster", c.Name())
+		urls = urls[:1]
+	}
+	slog.Info("/play playlist", "caster", c.Name(), "device", device, "videos", len(urls), "queue_only", queueOnly)
+	for i, u := range urls {
+		var err error
+		m := cast.Media{URL: u}
+		if i == 0 && !queueOnly {
+			err = c.Play(ctx, device, m)
+		} else {
+			err = c.Queue(ctx, device, m)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isVideoID reports whether id looks like a YouTube video id.
+func isVideoID(id string) bool {
+	if len(id) > 64 {
+		return false
+	}
+	for _, c := range id {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_') {
+			return false
+		}
+	}
+	return true
 }
 
-// parsePlayParams parses form/query and extracts type and url.
+// requireAction checks that the source registered for typ supports action.
+// Writes a 400 error and returns false otherwise.
+func requireAction(w nethttp.ResponseWriter, typ string, action source.Action) bool {
+	src := source.Lookup(typ)
+	if src == nil {
+		slog.Warn("unknown source type", "type", typ, "action", action)
+		httpError(w, nethttp.StatusBadRequest, "unknown type")
+		return false
+	}
+	if !source.Supports(src, action) {
+		slog.Warn("action not supported", "type", typ, "action", action)
+		httpError(w, nethttp.StatusBadRequest, fmt.Sprintf("%s not supported for %s", action, typ))
+		return false
+	}
+	return true
+}
+
+// parsePlayParams parses form/query and extracts type and url. An optional
+// start (seconds) is applied to the URL so casting begins at that offset.
 // Writes a 400 error on failure and returns ok=false.
 func parsePlayParams(w nethttp.ResponseWriter, r *nethttp.Request) (typ, u string, ok bool) {
 	if err := r.ParseForm(); err != nil {
@@ -159,148 +329,20 @@ func parsePlayParams(w nethttp.ResponseWriter, r *nethttp.Request) (typ, u strin
 		httpError(w, nethttp.StatusBadRequest, "missing url")
 		return "", "", false
 	}
-	return typ, u, true
-}
-
-// playSVT forwards the SVT URL to the configured endpoint. Returns an HTTP status
-// code to send on error.
-func (s *server) playSVT(ctx context.Context, svtURL string) (int, error) {
-	endpoint := s.svtEndpoint
-	if endpoint == "" {
-		endpoint = "http://localhost:18492/play"
-	}
-	// Compose full request URL including encoded svtURL
-	ep, err := url.Parse(endpoint)
-	if err != nil {
-		slog.Error("/play svtplay invalid endpoint", "endpoint", endpoint, "err", err)
-		return nethttp.StatusBadGateway, fmt.Errorf("invalid svt endpoint")
-	}
-	q := ep.Query()
-	q.Set("url", svtURL)
-	ep.RawQuery = q.Encode()
-	reqURL := ep.String()
-	slog.Info("/play svtplay request", "url", reqURL)
-
-	// Perform request via indirection (allows tests to stub)
-	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
-	defer cancel()
-	status, err := svtDoRequest(ctx, reqURL)
-	if err != nil {
-		slog.Error("/play svtplay call failed", "url", reqURL, "err", err)
-		return nethttp.StatusBadGateway, fmt.Errorf("svt call failed")
-	}
-	if status < 200 || status >= 300 {
-		slog.Warn("/play svtplay non-2xx", "status", status, "url", reqURL)
-		return nethttp.StatusBadGateway, fmt.Errorf("svt endpoint error")
-	}
-	slog.Info("/play svtplay forwarded", "url", reqURL)
-	return 0, nil
-}
-
-// playYouTube validates the URL and invokes ytcast with the configured device.
-// Returns an HTTP status code to send on error.
-func (s *server) playYouTube(ctx context.Context, u string) (int, error) {
-	parsed, err := url.Parse(u)
-	if err != nil || parsed.Scheme == "" || parsed.Host == "" {
-		slog.Warn("/play invalid url", "url", u, "err", err)
-		return nethttp.StatusBadRequest, fmt.Errorf("invalid url")
-	}
-	host := strings.ToLower(parsed.Host)
-	if !isYouTubeHost(host) {
-		slog.Warn("/play unsupported url host", "host", host)
-		return nethttp.StatusBadRequest, fmt.Errorf("unsupported url")
-	}
-	device := s.getYtcastDevice()
-	if device == "" {
-		slog.Warn("/play device not configured", "hint", "set -ytcast, YTCAST_DEVICE, or /ytcast/set-code")
-		return nethttp.StatusBadRequest, fmt.Errorf("ytcast device not configured")
-	}
-	// Execute ytcast with the provided URL
-	cctx, cancel := context.WithTimeout(ctx, execTimeout)
-	defer cancel()
-	bin, _ := exec.LookPath("ytcast")
-	args := []string{"-d", device, u}
-	cmd := exec.CommandContext(cctx, "ytcast", args...)
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
-	// Log full command line with quoting for troubleshooting
-	qargs := make([]string, 0, len(args))
-	for _, a := range args {
-		qargs = append(qargs, fmt.Sprintf("%q", a))
-	}
-	prog := bin
-	if prog == "" {
-		prog = "ytcast"
-	}
-	slog.Info("/play casting", "device", device, "url", u)
-	slog.Debug("/play exec", "prog", prog, "args", strings.Join(qargs, " "))
-	if err := cmd.Run(); err != nil {
-		exitCode := 0
-		if ee, ok := err.(*exec.ExitError); ok && ee.ProcessState != nil {
-			exitCode = ee.ProcessState.ExitCode()
-		}
-		outStr := strings.TrimSpace(stdout.String())
-		errStr := strings.TrimSpace(stderr.String())
-		slog.Error("/play ytcast failed", "err", err, "exit", exitCode, "stdout", outStr, "stderr", errStr)
-		return nethttp.StatusInternalServerError, fmt.Errorf("failed to cast")
-	}
-	return 0, nil
-}
-
-func isYouTubeHost(host string) bool {
-	return strings.HasSuffix(host, "youtube.com") || strings.HasSuffix(host, "youtu.be")
-}
-
-// queueYouTube validates the URL and invokes ytcast with -a to add to queue.
-// Returns an HTTP status code to send on error.
-func (s *server) queueYouTube(ctx context.Context, u string) (int, error) {
-	parsed, err := url.Parse(u)
-	if err != nil || parsed.Scheme == "" || parsed.Host == "" {
-		slog.Warn("/queue invalid url", "url", u, "err", err)
-		return nethttp.StatusBadRequest, fmt.Errorf("invalid url")
-	}
-	host := strings.ToLower(parsed.Host)
-	if !isYouTubeHost(host) {
-		slog.Warn("/queue unsupported url host", "host", host)
-		return nethttp.StatusBadRequest, fmt.Errorf("unsupported url")
-	}
-	device := s.getYtcastDevice()
-	if device == "" {
-		slog.Warn("/queue device not configured", "hint", "set -ytcast, YTCAST_DEVICE, or /ytcast/set-code")
-		return nethttp.StatusBadRequest, fmt.Errorf("ytcast device not configured")
-	}
-	// Execute ytcast with the provided URL and add flag
-	cctx, cancel := context.WithTimeout(ctx, execTimeout)
-	defer cancel()
-	bin, _ := exec.LookPath("ytcast")
-	args := []string{"-d", device, "-a", u}
-	cmd := exec.CommandContext(cctx, "ytcast", args...)
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
-	// Log full command line with quoting for troubleshooting
-	qargs := make([]string, 0, len(args))
-	for _, a := range args {
-		qargs = append(qargs, fmt.Sprintf("%q", a))
-	}
-	prog := bin
-	if prog == "" {
-		prog = "ytcast"
-	}
-	slog.Info("/queue casting", "device", device, "url", u)
-	slog.Debug("/queue exec", "prog", prog, "args", strings.Join(qargs, " "))
-	if err := cmd.Run(); err != nil {
-		exitCode := 0
-		if ee, ok := err.(*exec.ExitError); ok && ee.ProcessState != nil {
-			exitCode = ee.ProcessState.ExitCode()
+	if typ == "" {
+		// .url items carry no type; derive it from the URL
+		typ = source.ForURL(u).Name()
+	}
+	if v := r.FormValue("start"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			slog.Warn("/play invalid start", "start", v)
+			httpError(w, nethttp.StatusBadRequest, "invalid start")
+			return "", "", false
 		}
-		outStr := strings.TrimSpace(stdout.String())
-		errStr := strings.TrimSpace(stderr.String())
-		slog.Error("/queue ytcast failed", "err", err, "exit", exitCode, "stdout", outStr, "stderr", errStr)
-		return nethttp.StatusInternalServerError, fmt.Errorf("failed to cast")
+		u = source.WithStart(u, n)
 	}
-	return 0, nil
+	return typ, u, true
 }
 
 func (s *server) handleBrowse(w nethttp.ResponseWriter, r *nethttp.Request) {
@@ -309,8 +351,8 @@ func (s *server) handleBrowse(w nethttp.ResponseWriter, r *nethttp.Request) {
 		return
 	}
 
-	listing, err := browse.BuildListing(s.root, rel)
-	if err != nil {
+	listing, err := s.lib.Listing(rel)
+	if err != nil || browse.IgnoredPath(s.root, listing.Path, true) {
 		httpError(w, nethttp.StatusNotFound, "unable to read path")
 		return
 	}
@@ -324,7 +366,27 @@ func (s *server) handleBrowse(w nethttp.ResponseWriter, r *nethttp.Request) {
 		nethttp.Redirect(w, r, u.String(), nethttp.StatusMovedPermanently)
 		return
 	}
-	data := browsePageFromRequest(r, listing, rel)
+	cfg := browse.LoadDirConfig(s.root, listing.Path)
+	prefs, query := sortPrefsFromRequest(w, r, cfg)
+	recursive := query.Get("recursive") == "1"
+	if recursive {
+		// flattened view: every video under the folder, labelled by subfolder
+		entries, err := s.lib.VideosUnder(rel)
+		if err != nil {
+			httpError(w, nethttp.StatusNotFound, "unable to read path")
+			return
+		}
+		listing.Entries = entries
+	}
+	data := browsePageFromRequest(r, query, prefs, listing, rel)
+	data.Virtual = recursive
+	data.Recursive = recursive
+	if cfg.Title != "" && listing.Path != "" {
+		data.Breadcrumbs[len(data.Breadcrumbs)-1].Name = cfg.Title
+	}
+	if rel == "" && !recursive && data.Page == 1 {
+		data.Entries = append(s.homeEntries(), data.Entries...)
+	}
 	w.Header().Set("Content-Type", "text/html; charset=utf-8")
 	_ = s.tpl.Execute(w, data)
 }
@@ -357,8 +419,20 @@ func (s *server) getYtcastDevice() string {
 	return s.ytcastDevice
 }
 
-// handleYtcastPair validates a 12-digit pairing code and invokes
-// `ytcast -pair <code>`. Returns 204 on success, 400 on validation error,
+// deviceFor returns the device to cast a /play or /queue request to: the
+// device configured in .castweb.json for the item's folder (the "dir" form
+// value), or else the active ytcast device.
+func (s *server) deviceFor(r *nethttp.Request) string {
+	if dir := r.FormValue("dir"); dir != "" && browse.IsSubpath(s.root, filepath.Join(s.root, dir)) {
+		if d := browse.LoadDirConfig(s.root, dir).Device; d != "" {
+			return d
+		}
+	}
+	return s.getYtcastDevice()
+}
+
+// handleYtcastPair validates a 12-digit pairing code and pairs the YouTube
+// caster with it. Returns 204 on success, 400 on validation error,
 // and 500 on execution failure.
 func (s *server) handleYtcastPair(w nethttp.ResponseWriter, r *nethttp.Request) {
 	code := r.URL.Query().Get("code")
@@ -379,51 +453,46 @@ func (s *server) handleYtcastPair(w nethttp.ResponseWriter, r *nethttp.Request)
 			return
 		}
 	}
-	ctx, cancel := context.WithTimeout(r.Context(), execTimeout)
-	defer cancel()
-	// Run: ytcast -pair <code>
-	cmd := exec.CommandContext(ctx, "ytcast", "-pair", code)
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
-	slog.Info("/ytcast/pair exec", "code", code)
-	if err := cmd.Run(); err != nil {
-		outStr := strings.TrimSpace(stdout.String())
-		errStr := strings.TrimSpace(stderr.String())
-		slog.Error("/ytcast/pair failed", "err", err, "stdout", outStr, "stderr", errStr)
-		httpError(w, nethttp.StatusInternalServerError, "failed to pair")
+	c := s.casterFor(w, source.YouTube.Name(), s.getYtcastDevice(), cast.CapPair)
+	if c == nil {
+		return
+	}
+	slog.Info("/ytcast/pair exec", "caster", c.Name(), "code", code)
+	if err := c.Pair(r.Context(), code); err != nil {
+		castError(w, "/ytcast/pair", c, err, "failed to pair")
 		return
 	}
 	slog.Info("/ytcast/pair success", "code", code)
 	w.WriteHeader(nethttp.StatusNoContent)
 }
 
-// handleYtcastList invokes `ytcast -l` and writes its stdout as text/plain.
-// Returns 200 on success, 500 on failure.
+// handleYtcastList lists the devices known to the YouTube caster as
+// text/plain, one per line. Returns 200 on success, 500 on failure.
 func (s *server) handleYtcastList(w nethttp.ResponseWriter, r *nethttp.Request) {
-	ctx, cancel := context.WithTimeout(r.Context(), execTimeout)
-	defer cancel()
-	defer cancel()
-	cmd := exec.CommandContext(ctx, "ytcast", "-l")
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
-	slog.Info("/ytcast/list exec")
-	if err := cmd.Run(); err != nil {
-		outStr := strings.TrimSpace(stdout.String())
-		errStr := strings.TrimSpace(stderr.String())
-		slog.Error("/ytcast/list failed", "err", err, "stdout", outStr, "stderr", errStr)
-		httpError(w, nethttp.StatusInternalServerError, "failed to list devices")
+	c := s.casterFor(w, source.YouTube.Name(), s.getYtcastDevice(), cast.CapList)
+	if c == nil {
+		return
+	}
+	slog.Info("/ytcast/list exec", "caster", c.Name())
+	devs, err := c.ListDevices(r.Context())
+	if err != nil {
+		castError(w, "/ytcast/list", c, err, "failed to list devices")
 		return
 	}
-	slog.Info("/ytcast/list success", "bytes", stdout.Len())
+	var b strings.Builder
+	for _, d := range devs {
+		b.WriteString(d.String())
+		b.WriteByte('\n')
+	}
+	slog.Info("/ytcast/list success", "devices", len(devs))
 	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
 	w.WriteHeader(nethttp.StatusOK)
-	_, _ = w.Write(stdout.Bytes())
+	_, _ = w.Write([]byte(b.String()))
 }
 
-// handleYtcastSetCode stores a code (as-is) to be used as the device
-// argument for subsequent `ytcast -d` calls (e.g., in /play).
+// handleYtcastSetCode stores a code (as-is) as the active device: a screen
+// name or ID for the YouTube caster, or whatever device the routed caster
+// takes, passed on by /play and friends unless a folder sets its own.
 // Returns 204 on success, 400 when missing the code parameter.
 func (s *server) handleYtcastSetCode(w nethttp.ResponseWriter, r *nethttp.Request) {
 	code := r.URL.Query().Get("code")
@@ -436,13 +505,6 @@ func (s *server) handleYtcastSetCode(w nethttp.ResponseWriter, r *nethttp.Reques
 	s.ytcastCode = code
 	s.mu.Unlock()
 	slog.Info("/ytcast/set-code set", "code", code)
-	if s.stateDir != "" {
-		statePath := filepath.Join(s.stateDir, "state.json")
-		if err := store.SaveState(statePath, store.State{YtcastCode: code}); err != nil {
-			slog.Error("/ytcast/set-code persist failed", "err", err)
-		} else {
-			slog.Info("/ytcast/set-code persisted", "path", statePath)
-		}
-	}
+	s.saveState("/ytcast/set-code")
 	w.WriteHeader(nethttp.StatusNoContent)
 }
@@ -30,6 +30,16 @@ type browsePageData struct {
 	ParentPath  string
 	Entries     []model.Entry
 	Breadcrumbs []breadcrumb
+	Query       string   // search query, set on /search
+	Terms       []string // search terms to highlight
+	// Virtual is set for listings spanning several folders (search, tags);
+	// each video entry's Path then holds its folder.
+	Virtual bool
+	// Sort controls, shown when SortOrders is set
+	SortOrders   []browse.SortOrder
+	Sort         browse.SortOrder
+	FoldersFirst bool
+	Recursive    bool // flattened view of the folder's subtree
 }
 
 type pairPageData struct {
@@ -70,7 +80,7 @@ func (s *server) serveImage(w nethttp.ResponseWriter, r *nethttp.Request, rel st
 		return false
 	}
 	fi, err := os.Stat(full)
-	if err != nil || !fi.Mode().IsRegular() {
+	if err != nil || !fi.Mode().IsRegular() || browse.IgnoredPath(s.root, rel, false) {
 		return false
 	}
 	switch {
@@ -84,21 +94,25 @@ func (s *server) serveImage(w nethttp.ResponseWriter, r *nethttp.Request, rel st
 	return true
 }
 
-func browsePageFromRequest(r *nethttp.Request, listing model.Listing, rel string) browsePageData {
+func browsePageFromRequest(r *nethttp.Request, query url.Values, prefs sortPrefs, listing model.Listing, rel string) browsePageData {
+	browse.SortEntries(listing.Entries, prefs.Order, prefs.FoldersFirst, prefs.Seed)
 	page := currentPage(r)
 	start, end, hasPrev, hasNext := pageBounds(page, len(listing.Entries))
-	prevURL, nextURL := pageLinks(r.URL.Query(), rel, page, hasPrev, hasNext)
+	prevURL, nextURL := pageLinks(query, encodedBrowsePath(rel), page, hasPrev, hasNext)
 
 	return browsePageData{
-		Page:        page,
-		HasPrev:     hasPrev,
-		HasNext:     hasNext,
-		PrevURL:     prevURL,
-		NextURL:     nextURL,
-		Path:        listing.Path,
-		ParentPath:  listing.ParentPath,
-		Entries:     listing.Entries[start:end],
-		Breadcrumbs: breadcrumbsFor(listing.Path),
+		Page:         page,
+		HasPrev:      hasPrev,
+		HasNext:      hasNext,
+		PrevURL:      prevURL,
+		NextURL:      nextURL,
+		Path:         listing.Path,
+		ParentPath:   listing.ParentPath,
+		Entries:      listing.Entries[start:end],
+		Breadcrumbs:  breadcrumbsFor(listing.Path),
+		SortOrders:   browse.SortOrders,
+		Sort:         prefs.Order,
+		FoldersFirst: prefs.FoldersFirst,
 	}
 }
 
@@ -126,8 +140,7 @@ func pageBounds(page, total int) (start, end int, hasPrev, hasNext bool) {
 	return start, end, hasPrev, hasNext
 }
 
-func pageLinks(query url.Values, rel string, page int, hasPrev, hasNext bool) (prevURL, nextURL string) {
-	base := encodedBrowsePath(rel)
+func pageLinks(query url.Values, base string, page int, hasPrev, hasNext bool) (prevURL, nextURL string) {
 	q := cloneValues(query)
 	if hasPrev {
 		q.Set("page", strconv.Itoa(page-1))
@@ -0,0 +1,156 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	nethttp "net/http"
+
+	"github.com/claes/ytplv/internal/cast"
+	"github.com/claes/ytplv/internal/lounge"
+)
+
+// loungeBaseURL is the lounge API used by the default YouTube caster. It is
+// declared as a variable to allow tests to point it at a fake server.
+var loungeBaseURL = lounge.DefaultBaseURL
+
+// svtDoRequest is used by the default SVT Play caster to forward URLs to its
+// endpoint. It is declared as a variable to allow tests to stub it out
+// without network access.
+var svtDoRequest = cast.Get
+
+// defaultCasters returns the built-in setup: the YouTube lounge client for
+// YouTube and the SVT Play forwarder at svtEndpoint for SVT Play.
+func defaultCasters(svtEndpoint string) *cast.Router {
+	yt := cast.NewYouTube("youtube")
+	yt.Client.BaseURL = loungeBaseURL
+	svt := cast.NewSVTPlay("svtplay", svtEndpoint)
+	svt.Do = func(ctx context.Context, requestURL string) (int, error) {
+		return svtDoRequest(ctx, requestURL)
+	}
+	r, err := cast.NewRouter([]cast.Caster{yt, svt}, []cast.Route{
+		{Source: "youtube", Caster: yt.Name()},
+		{Source: "youtube-playlist", Caster: yt.Name()},
+		{Source: "svtplay", Caster: svt.Name()},
+	})
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+// LoadCasterState implements cast.StateStore over the "casters" entry of
+// state.json.
+func (s *server) LoadCasterState(caster string, v any) error {
+	s.mu.RLock()
+	b, ok := s.casterState[caster]
+	s.mu.RUnlock()
+	if !ok {
+		return nil
+	}
+	return json.Unmarshal(b, v)
+}
+
+// SaveCasterState implements cast.StateStore, persisting state.json.
+func (s *server) SaveCasterState(caster string, v any) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	if s.casterState == nil {
+		s.casterState = make(map[string]json.RawMessage)
+	}
+	s.casterState[caster] = b
+	s.mu.Unlock()
+	s.saveState("caster " + caster)
+	return nil
+}
+
+// casterFor returns the caster routed for items of type typ cast to device.
+// Writes a 400 error and returns nil when there is none or it lacks cp.
+func (s *server) casterFor(w nethttp.ResponseWriter, typ, device string, cp cast.Capability) cast.Caster {
+	c := s.casters.Pick(typ, device)
+	if c == nil {
+		slog.Warn("no caster for source", "type", typ, "device", device)
+		httpError(w, nethttp.StatusBadRequest, fmt.Sprintf("no caster for %s", typ))
+		return nil
+	}
+	if !cast.Supports(c, cp) {
+		slog.Warn("caster lacks capability", "caster", c.Name(), "capability", cp)
+		httpError(w, nethttp.StatusBadRequest, fmt.Sprintf("%s not supported by %s", cp, c.Name()))
+		return nil
+	}
+	return c
+}
+
+// handleControl returns the handler of /stop or /pause: cp on the caster
+// routed for the type and dir form values, as for /play. Returns 204 on
+// success and 409 when nothing is playing.
+func (s *server) handleControl(route string, cp cast.Capability) nethttp.HandlerFunc {
+	return func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		if err := r.ParseForm(); err != nil {
+			slog.Warn(route+" parse error", "err", err)
+			httpError(w, nethttp.StatusBadRequest, "invalid form")
+			return
+		}
+		device := s.deviceFor(r)
+		c := s.casterFor(w, r.FormValue("type"), device, cp)
+		if c == nil {
+			return
+		}
+		ctl, ok := c.(cast.Controller)
+		if !ok {
+			slog.Warn(route+" caster has no playback control", "caster", c.Name())
+			httpError(w, nethttp.StatusBadRequest, fmt.Sprintf("%s not supported by %s", cp, c.Name()))
+			return
+		}
+		slog.Info(route, "caster", c.Name(), "device", device)
+		var err error
+		if cp == cast.CapStop {
+			err = ctl.Stop(r.Context(), device)
+		} else {
+			err = ctl.PlayPause(r.Context(), device)
+		}
+		if err != nil {
+			castError(w, route, c, err, "failed to control playback")
+			return
+		}
+		w.WriteHeader(nethttp.StatusNoContent)
+	}
+}
+
+// castError answers a failed caster call: 400 for requests the caster
+// rejected (including unknown pairing codes), 409 when there is nothing to
+// control, 502 when a remote service failed and 500 (with msg) otherwise.
+func castError(w nethttp.ResponseWriter, route string, c cast.Caster, err error, msg string) {
+	var ce *cast.Error
+	switch {
+	case errors.Is(err, cast.ErrNoDevice):
+		slog.Warn(route+" device not configured", "caster", c.Name(), "hint", "set -ytcast, YTCAST_DEVICE, /ytcast/set-code or a folder device")
+		httpError(w, nethttp.StatusBadRequest, "device not configured")
+	case errors.Is(err, cast.ErrInvalidURL):
+		slog.Warn(route+" invalid url", "caster", c.Name(), "err", err)
+		httpError(w, nethttp.StatusBadRequest, "invalid url")
+	case errors.Is(err, cast.ErrUnsupportedURL):
+		slog.Warn(route+" unsupported url", "caster", c.Name(), "err", err)
+		httpError(w, nethttp.StatusBadRequest, "unsupported url")
+	case errors.Is(err, cast.ErrInvalidCode):
+		slog.Warn(route+" invalid pairing code", "caster", c.Name(), "err", err)
+		httpError(w, nethttp.StatusBadRequest, "invalid pairing code")
+	case errors.Is(err, cast.ErrNotSupported):
+		slog.Warn(route+" not supported", "caster", c.Name(), "err", err)
+		httpError(w, nethttp.StatusBadRequest, "not supported")
+	case errors.Is(err, cast.ErrNotPlaying):
+		slog.Warn(route+" nothing playing", "caster", c.Name())
+		httpError(w, nethttp.StatusConflict, "nothing is playing")
+	case errors.As(err, &ce) && ce.Remote:
+		slog.Error(route+" failed", "caster", c.Name(), "err", err)
+		httpError(w, nethttp.StatusBadGateway, msg)
+	default:
+		slog.Error(route+" failed", "caster", c.Name(), "err", err)
+		httpError(w, nethttp.StatusInternalServerError, msg)
+	}
+}
@@ -0,0 +1,204 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	nethttp "net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/claes/ytplv/internal/cast"
+)
+
+// recordingCaster is a caster that records play, queue and control calls.
+type recordingCaster struct {
+	name  string
+	caps  []cast.Capability
+	calls []string
+	strm  string // STRM of the last play
+}
+
+func (c *recordingCaster) Name() string                    { return c.name }
+func (c *recordingCaster) Capabilities() []cast.Capability { return c.caps }
+func (c *recordingCaster) Play(_ context.Context, device string, m cast.Media) error {
+	c.calls = append(c.calls, "play "+device+" "+m.URL)
+	c.strm = m.STRM
+	return nil
+}
+func (c *recordingCaster) Queue(_ context.Context, device string, m cast.Media) error {
+	c.calls = append(c.calls, "queue "+device+" "+m.URL)
+	return nil
+}
+func (c *recordingCaster) Pair(context.Context, string) error { return cast.ErrNotSupported }
+func (c *recordingCaster) Stop(_ context.Context, device string) error {
+	c.calls = append(c.calls, "stop "+device)
+	return nil
+}
+func (c *recordingCaster) PlayPause(context.Context, string) error { return cast.ErrNotPlaying }
+func (c *recordingCaster) ListDevices(context.Context) ([]cast.Device, error) {
+	return []cast.Device{{ID: "tv1", Name: "Living room"}, {Name: "Kitchen"}}, nil
+}
+
+func TestCasters_RoutedBySourceAndDevice(t *testing.T) {
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "kids"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "kids", ".castweb.json"), []byte(`{"device": "kids-tv"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	living := &recordingCaster{name: "living", caps: []cast.Capability{cast.CapPlay, cast.CapQueue, cast.CapList}}
+	kids := &recordingCaster{name: "kids", caps: []cast.Capability{cast.CapPlay}}
+	router, err := cast.NewRouter([]cast.Caster{living, kids}, []cast.Route{
+		{Device: "kids-tv", Caster: "kids"},
+		{Source: "youtube", Caster: "living"},
+		{Source: "youtube-playlist", Caster: "living"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	mux := New(Config{Root: root, YtcastDevice: "tv", Casters: router})
+	yt := url.QueryEscape("https://www.youtube.com/watch?v=abc")
+	for _, tc := range []struct {
+		target string
+		code   int
+	}{
+		{"/play?type=youtube&url=" + yt, 204},
+		{"/queue?type=youtube&url=" + yt, 204},
+		{"/play?type=youtube-playlist&url=x&ids=a1,b2", 204},
+		{"/play?type=youtube&url=" + yt + "&dir=kids", 204},
+		{"/queue?type=youtube&url=" + yt + "&dir=kids", 400}, // kids caster cannot queue
+		{"/play?type=svtplay&url=" + url.QueryEscape("https://www.svtplay.se/x"), 400},
+	} {
+		rr := httptest.NewRecorder()
+		mux.ServeHTTP(rr, httptest.NewRequest("POST", tc.target, nil))
+		if rr.Code != tc.code {
+			t.Errorf("%s: got %d, want %d; body=%s", tc.target, rr.Code, tc.code, rr.Body.String())
+		}
+	}
+	wantLiving := []string{
+		"play tv https://www.youtube.com/watch?v=abc",
+		"queue tv https://www.youtube.com/watch?v=abc",
+		"play tv https://www.youtube.com/watch?v=a1",
+		"queue tv https://www.youtube.com/watch?v=b2",
+	}
+	if !reflect.DeepEqual(living.calls, wantLiving) {
+		t.Errorf("living calls = %q, want %q", living.calls, wantLiving)
+	}
+	if want := []string{"play kids-tv https://www.youtube.com/watch?v=abc"}; !reflect.DeepEqual(kids.calls, want) {
+		t.Errorf("kids calls = %q, want %q", kids.calls, want)
+	}
+
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest("GET", "/ytcast/list", nil))
+	if rr.Code != 200 || rr.Body.String() != "tv1\tLiving room\nKitchen\n" {
+		t.Errorf("list: %d %q", rr.Code, rr.Body.String())
+	}
+	rr = httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest("GET", "/ytcast/pair?code=123456789012", nil))
+	if rr.Code != 400 {
+		t.Errorf("pair without capability: got %d", rr.Code)
+	}
+}
+
+func TestCasters_StrmAndControl(t *testing.T) {
+	kodi := &recordingCaster{name: "kodi", caps: []cast.Capability{cast.CapPlay, cast.CapStop, cast.CapPause}}
+	router, err := cast.NewRouter([]cast.Caster{kodi}, []cast.Route{{Caster: "kodi"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := t.TempDir()
+	strm := "plugin://plugin.video.youtube/play/?video_id=abc"
+	if err := os.MkdirAll(filepath.Join(root, "clips"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range map[string]string{"abc.strm": strm, "abc.nfo": "<movie><title>A</title></movie>"} {
+		if err := os.WriteFile(filepath.Join(root, "clips", name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	mux := New(Config{Root: root, YtcastDevice: "den", Casters: router})
+	for _, tc := range []struct {
+		form url.Values
+		want string
+	}{
+		// the line comes from the library; a posted strm is ignored
+		{url.Values{"url": {"https://www.youtube.com/watch?v=abc"}, "dir": {"clips"}, "strm": {"plugin://script.example/?video_id=abc"}}, strm},
+		{url.Values{"url": {"https://www.youtube.com/watch?v=other"}, "dir": {"clips"}, "strm": {"plugin://plugin.video.youtube/play/?video_id=other"}}, ""},
+		{url.Values{"url": {"https://www.youtube.com/watch?v=abc"}, "dir": {"../clips"}}, ""},
+		{url.Values{"url": {"https://www.youtube.com/watch?v=abc"}}, ""},
+	} {
+		tc.form.Set("type", "youtube")
+		rr := httptest.NewRecorder()
+		mux.ServeHTTP(rr, httptest.NewRequest("POST", "/play?"+tc.form.Encode(), nil))
+		if rr.Code != 204 || kodi.strm != tc.want {
+			t.Fatalf("play %v: %d, strm %q, want %q", tc.form, rr.Code, kodi.strm, tc.want)
+		}
+	}
+
+	for _, tc := range []struct {
+		target string
+		code   int
+	}{
+		{"/stop?type=youtube", 204},
+		{"/pause", 409}, // nothing playing
+	} {
+		rr := httptest.NewRecorder()
+		mux.ServeHTTP(rr, httptest.NewRequest("POST", tc.target, nil))
+		if rr.Code != tc.code {
+			t.Errorf("%s: got %d, want %d; body=%s", tc.target, rr.Code, tc.code, rr.Body.String())
+		}
+	}
+	if last := kodi.calls[len(kodi.calls)-1]; last != "stop den" {
+		t.Errorf("last call = %q", last)
+	}
+}
+
+func TestCasters_RokuFromConfig(t *testing.T) {
+	var launches []string
+	roku := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		if r.Method == nethttp.MethodPost {
+			launches = append(launches, r.URL.RequestURI())
+		}
+	}))
+	defer roku.Close()
+	u, err := url.Parse(roku.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := fmt.Sprintf(`{
+		"casters": [{"name": "bedroom", "type": "roku", "host": %q, "port": %s}],
+		"routes": [{"source": "youtube", "caster": "bedroom"}, {"source": "youtube-playlist", "caster": "bedroom"}]
+	}`, u.Hostname(), u.Port())
+	path := filepath.Join(t.TempDir(), "cast.json")
+	if err := os.WriteFile(path, []byte(cfg), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	router, err := cast.LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mux := New(Config{Root: t.TempDir(), Casters: router})
+	for _, tc := range []struct {
+		target string
+		code   int
+	}{
+		{"/play?type=youtube&url=" + url.QueryEscape("https://youtu.be/abc"), 204},
+		{"/queue?type=youtube&url=" + url.QueryEscape("https://youtu.be/abc"), 400}, // no queue on a Roku
+		{"/play?type=youtube-playlist&url=x&ids=a1,b2", 204},                        // first video only
+	} {
+		rr := httptest.NewRecorder()
+		mux.ServeHTTP(rr, httptest.NewRequest("POST", tc.target, nil))
+		if rr.Code != tc.code {
+			t.Errorf("%s: got %d, want %d; body=%s", tc.target, rr.Code, tc.code, rr.Body.String())
+		}
+	}
+	want := []string{"/launch/837?contentId=abc&mediaType=movie", "/launch/837?contentId=a1&mediaType=movie"}
+	if !reflect.DeepEqual(launches, want) {
+		t.Errorf("launches = %q, want %q", launches, want)
+	}
+}
@@ -0,0 +1,33 @@
+package http
+
+import (
+	"encoding/json"
+	"log/slog"
+	nethttp "net/http"
+
+	"github.com/claes/ytplv/internal/browse"
+)
+
+type diagnosticsResponse struct {
+	Path        string              `json:"path"`
+	Count       int                 `json:"count"`
+	Diagnostics []browse.Diagnostic `json:"diagnostics"`
+}
+
+// handleDiagnostics reports why items under ?path= (default: the whole root)
+// are skipped or incomplete, as JSON. Returns 404 if the path cannot be read.
+func (s *server) handleDiagnostics(w nethttp.ResponseWriter, r *nethttp.Request) {
+	rel := r.URL.Query().Get("path")
+	diags, err := browse.Lint(s.root, rel)
+	if err != nil {
+		slog.Warn("/api/diagnostics failed", "path", rel, "err", err)
+		httpError(w, nethttp.StatusNotFound, "unable to read path")
+		return
+	}
+	if diags == nil {
+		diags = []browse.Diagnostic{}
+	}
+	slog.Info("/api/diagnostics", "path", rel, "count", len(diags))
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(diagnosticsResponse{Path: rel, Count: len(diags), Diagnostics: diags})
+}
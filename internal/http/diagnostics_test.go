@@ -0,0 +1,39 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDiagnostics_ReportsOrphanNFO(t *testing.T) {
+	root := t.TempDir()
+	if err := os.Mkdir(filepath.Join(root, "a"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "a", "x.nfo"), []byte("<movie/>"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	mux := NewServer(root, "", "", "")
+
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest("GET", "/api/diagnostics", nil))
+	if rr.Code != 200 {
+		t.Fatalf("expected 200, got %d; body=%s", rr.Code, rr.Body.String())
+	}
+	var body diagnosticsResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &body); err != nil {
+		t.Fatalf("invalid json: %v", err)
+	}
+	if body.Count != 1 || body.Diagnostics[0].Path != "a/x.nfo" || body.Diagnostics[0].Code != "orphan-nfo" {
+		t.Fatalf("unexpected diagnostics: %+v", body)
+	}
+
+	rr = httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest("GET", "/api/diagnostics?path=../..", nil))
+	if rr.Code != 404 {
+		t.Fatalf("expected 404 for path outside root, got %d", rr.Code)
+	}
+}
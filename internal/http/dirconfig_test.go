@@ -0,0 +1,106 @@
+package http
+
+import (
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBrowse_FolderConfig(t *testing.T) {
+	root := t.TempDir()
+	arch := filepath.Join(root, "archive")
+	if err := os.Mkdir(arch, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	writeSortItems(t, arch, 3)
+	if err := os.WriteFile(filepath.Join(arch, ".castweb.json"), []byte(`{"title": "The Archive", "sort": "title", "folders_first": true}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, ".castwebignore"), []byte("secret/\nprivate.jpg\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(root, "secret", "sub"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	mux := NewServer(root, "", "", "")
+
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest("GET", "/archive/", nil))
+	body := rr.Body.String()
+	if !strings.Contains(body, `<option value="title" selected>`) {
+		t.Fatalf("expected title order from folder config")
+	}
+	if i, j := strings.Index(body, `data-path="archive/zfolder"`), strings.Index(body, ">Title 0<"); i < 0 || j < 0 || i > j {
+		t.Fatalf("expected folder before videos")
+	}
+	if !strings.Contains(body, "The Archive") {
+		t.Fatalf("expected config title in breadcrumb")
+	}
+	if len(rr.Result().Cookies()) != 0 {
+		t.Fatalf("folder config must not be remembered in cookies")
+	}
+
+	// an explicit parameter still wins
+	rr = httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest("GET", "/archive/?sort=name", nil))
+	if !strings.Contains(rr.Body.String(), `<option value="name" selected>`) {
+		t.Fatalf("expected name order from parameter")
+	}
+
+	rr = httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest("GET", "/", nil))
+	if strings.Contains(rr.Body.String(), `data-path="secret"`) {
+		t.Fatalf("ignored folder listed")
+	}
+	for _, p := range []string{"/secret/", "/secret/sub/"} {
+		rr = httptest.NewRecorder()
+		mux.ServeHTTP(rr, httptest.NewRequest("GET", p, nil))
+		if rr.Code != 404 {
+			t.Fatalf("ignored folder %s served: %d", p, rr.Code)
+		}
+	}
+	for _, p := range []string{"/play", "/queue"} {
+		rr = httptest.NewRecorder()
+		mux.ServeHTTP(rr, httptest.NewRequest("POST", p+"?type=youtube&url=https://youtu.be/abc&dir=secret/sub", nil))
+		if rr.Code != 404 {
+			t.Fatalf("%s from an ignored folder: %d", p, rr.Code)
+		}
+	}
+
+	for _, p := range []string{"archive/cover.jpg", "archive/private.jpg", "secret/poster.jpg"} {
+		if err := os.WriteFile(filepath.Join(root, p), []byte("jpg"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for p, code := range map[string]int{"archive/cover.jpg": 200, "archive/private.jpg": 404, "secret/poster.jpg": 404} {
+		rr = httptest.NewRecorder()
+		mux.ServeHTTP(rr, httptest.NewRequest("GET", "/"+p, nil))
+		if rr.Code != code {
+			t.Fatalf("GET /%s = %d, want %d", p, rr.Code, code)
+		}
+	}
+}
+
+func TestDeviceFor_FolderConfig(t *testing.T) {
+	root := t.TempDir()
+	kids := filepath.Join(root, "kids", "cartoons")
+	if err := os.MkdirAll(kids, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "kids", ".castweb.json"), []byte(`{"device": "kids-tv", "inherit": true}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s := &server{root: root, ytcastDevice: "living-room"}
+	for dir, want := range map[string]string{
+		"":              "living-room",
+		"kids/cartoons": "kids-tv",
+		"../elsewhere":  "living-room",
+	} {
+		r := httptest.NewRequest("POST", "/play?dir="+dir, nil)
+		if got := s.deviceFor(r); got != want {
+			t.Errorf("deviceFor(%q) = %q, want %q", dir, got, want)
+		}
+	}
+}
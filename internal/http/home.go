@@ -0,0 +1,144 @@
+package http
+
+import (
+	"fmt"
+	nethttp "net/http"
+	"sort"
+	"time"
+
+	"github.com/claes/ytplv/internal/model"
+)
+
+// DefaultRecentDays is how far back the home folders look by default.
+const DefaultRecentDays = 14
+
+// URL prefixes of the virtual home folders. Like tagsPath, they shadow
+// library folders of the same name.
+const (
+	recentPath   = "recent"
+	continuePath = "continue"
+)
+
+// homeFolder is a virtual folder shown at the top of the root listing.
+type homeFolder struct {
+	path  string
+	title string
+	plot  string
+	items func(s *server, videos []model.Entry) []model.Entry // picks from s.lib.Videos()
+}
+
+func (s *server) homeFolders() []homeFolder {
+	return []homeFolder{
+		{continuePath, "Continue watching", "Resume point saved but not finished, or queued but not played", (*server).continueWatching},
+		{recentPath, "Recently added", fmt.Sprintf("Added in the last %d days", s.recentDays), (*server).recentlyAdded},
+	}
+}
+
+// homeEntries returns the folder entries for the home folders that have
+// items, for the top of the first root page.
+func (s *server) homeEntries() []model.Entry {
+	var out []model.Entry
+	videos := s.lib.Videos()
+	for _, hf := range s.homeFolders() {
+		items := hf.items(s, videos)
+		if len(items) == 0 {
+			continue
+		}
+		out = append(out, model.Entry{
+			Kind:    "dir",
+			Name:    hf.title,
+			Path:    hf.path,
+			ModTime: items[0].ModTime,
+			Folder:  &model.Folder{Title: fmt.Sprintf("%s (%d)", hf.title, len(items)), Plot: hf.plot},
+		})
+	}
+	return out
+}
+
+// handleHome serves a home folder as a listing spanning the library.
+func (s *server) handleHome(hf homeFolder) nethttp.HandlerFunc {
+	return func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		if r.URL.Path != "/"+hf.path+"/" {
+			httpError(w, nethttp.StatusNotFound, "not found")
+			return
+		}
+		entries := hf.items(s, s.lib.Videos())
+		page := currentPage(r)
+		start, end, hasPrev, hasNext := pageBounds(page, len(entries))
+		prevURL, nextURL := pageLinks(r.URL.Query(), "/"+hf.path+"/", page, hasPrev, hasNext)
+		data := browsePageData{
+			Page:        page,
+			HasPrev:     hasPrev,
+			HasNext:     hasNext,
+			PrevURL:     prevURL,
+			NextURL:     nextURL,
+			Path:        hf.path,
+			Entries:     entries[start:end],
+			Breadcrumbs: []breadcrumb{{Name: "Root", Href: "/"}, {Name: hf.title, Current: true}},
+			Virtual:     true,
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_ = s.tpl.Execute(w, data)
+	}
+}
+
+// recentlyAdded returns the videos that first appeared in the library within
+// the recent window, newest first.
+func (s *server) recentlyAdded(videos []model.Entry) []model.Entry {
+	since := time.Now().AddDate(0, 0, -s.recentDays)
+	var out []model.Entry
+	for _, e := range videos {
+		if e.Added.After(since) {
+			out = append(out, e)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Added.After(out[j].Added) })
+	return out
+}
+
+// continueWatching returns the videos with a resume point short of the end
+// recorded in their NFO, and those queued through castweb within the recent
+// window and not played since. The most recently active come first.
+//
+// This is narrower than "started but not finished": an item cast through
+// castweb is not listed unless its NFO later records a resume point, since
+// castweb cannot tell how far it was watched, and an NFO without a playcount
+// does not say whether it was ever started.
+func (s *server) continueWatching(videos []model.Entry) []model.Entry {
+	since := time.Now().AddDate(0, 0, -s.recentDays)
+	type hit struct {
+		e    model.Entry
+		when time.Time
+	}
+	var hits []hit
+	for _, e := range videos {
+		v := e.Video
+		a, ok := s.activityFor(v)
+		resumable := v.Resume > 0 && (v.Total == 0 || v.Resume < v.Total)
+		queued := ok && a.Last().After(since) && a.Queued.After(a.Played)
+		if !resumable && !queued {
+			continue
+		}
+		when := a.Last()
+		if when.IsZero() {
+			when = lastPlayed(v)
+		}
+		hits = append(hits, hit{e, when})
+	}
+	sort.SliceStable(hits, func(i, j int) bool { return hits[i].when.After(hits[j].when) })
+	out := make([]model.Entry, len(hits))
+	for i, h := range hits {
+		out[i] = h.e
+	}
+	return out
+}
+
+// lastPlayed parses the NFO lastplayed date, zero if absent.
+func lastPlayed(v *model.Video) time.Time {
+	for _, layout := range []string{"2006-01-02 15:04:05", "2006-01-02"} {
+		if t, err := time.Parse(layout, v.LastPlayed); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
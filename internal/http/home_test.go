@@ -0,0 +1,103 @@
+package http
+
+import (
+	nethttp "net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/claes/ytplv/internal/cast"
+)
+
+func writeHomeItem(t *testing.T, root, name, strm, nfo string, mtime time.Time) {
+	t.Helper()
+	base := filepath.Join(root, name)
+	if err := os.WriteFile(base+".strm", []byte(strm), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(base+".nfo", []byte(nfo), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(base+".strm", mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func get(t *testing.T, mux nethttp.Handler, target string) string {
+	t.Helper()
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest("GET", target, nil))
+	return rr.Body.String()
+}
+
+func TestHome_RecentlyAdded(t *testing.T) {
+	root := t.TempDir()
+	now := time.Now()
+	writeHomeItem(t, root, "new", "plugin://plugin.video.youtube/play/?video_id=new", "<movie><title>Fresh</title></movie>", now)
+	writeHomeItem(t, root, "old", "plugin://plugin.video.youtube/play/?video_id=old", "<movie><title>Stale</title></movie>", now.AddDate(0, 0, -30))
+	mux := New(Config{Root: root, RecentDays: 7})
+
+	body := get(t, mux, "/")
+	if !strings.Contains(body, "Recently added (1)") || strings.Contains(body, "Continue watching") {
+		t.Fatalf("expected only the recently added folder; body=%s", body)
+	}
+	if i, j := strings.Index(body, "Recently added"), strings.Index(body, "Fresh"); i > j {
+		t.Fatalf("expected home folders at the top")
+	}
+	if strings.Contains(get(t, mux, "/?page=2"), "Recently added") {
+		t.Fatalf("home folders belong on the first page only")
+	}
+	body = get(t, mux, "/recent/")
+	if !strings.Contains(body, "Fresh") || strings.Contains(body, "Stale") {
+		t.Fatalf("unexpected recent listing; body=%s", body)
+	}
+}
+
+func TestHome_ContinueWatching(t *testing.T) {
+	root := t.TempDir()
+	state := t.TempDir()
+	old := time.Now().AddDate(0, 0, -60)
+	writeHomeItem(t, root, "resume", "plugin://plugin.video.youtube/play/?video_id=res", "<movie><title>Halfway</title><resume><position>600.0</position><total>1800.0</total></resume></movie>", old)
+	writeHomeItem(t, root, "done", "plugin://plugin.video.youtube/play/?video_id=done", "<movie><title>Finished</title><resume><position>0</position><total>0</total></resume></movie>", old)
+	writeHomeItem(t, root, "next", "plugin://plugin.video.youtube/play/?video_id=next", "<movie><title>Up Next</title></movie>", old)
+	tv := &recordingCaster{name: "tv", caps: []cast.Capability{cast.CapPlay, cast.CapQueue}}
+	router, err := cast.NewRouter([]cast.Caster{tv}, []cast.Route{{Caster: "tv"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := Config{Root: root, StateDir: state, YtcastDevice: "tv", Casters: router}
+	mux := New(cfg)
+
+	body := get(t, mux, "/continue/")
+	if !strings.Contains(body, "Halfway") || strings.Contains(body, "Finished") || strings.Contains(body, "Up Next") {
+		t.Fatalf("expected only the NFO resume item; body=%s", body)
+	}
+
+	for path, id := range map[string]string{"/play": "done", "/queue": "next"} {
+		rr := httptest.NewRecorder()
+		form := url.Values{"type": {"youtube"}, "id": {id}, "url": {"https://www.youtube.com/watch?v=" + id}}
+		req := httptest.NewRequest("POST", path, strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		mux.ServeHTTP(rr, req)
+		if rr.Code != 204 {
+			t.Fatalf("%s: expected 204, got %d", path, rr.Code)
+		}
+	}
+
+	// activity survives a restart through state.json; playing alone does
+	// not make an item started
+	body = get(t, New(cfg), "/continue/")
+	if i, j := strings.Index(body, "Up Next"), strings.Index(body, "Halfway"); i < 0 || j < 0 || i > j {
+		t.Fatalf("expected the queued item first; body=%s", body)
+	}
+	if strings.Contains(body, "Finished") {
+		t.Fatalf("a played item without a resume point is not started; body=%s", body)
+	}
+	if !strings.Contains(get(t, mux, "/"), "Continue watching (2)") {
+		t.Fatalf("expected continue watching folder on the root page")
+	}
+}
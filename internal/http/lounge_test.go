@@ -0,0 +1,80 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/claes/ytplv/internal/lounge/loungetest"
+	"github.com/claes/ytplv/internal/store"
+)
+
+func TestDefaultYouTubeCaster_PairsAndPersistsScreens(t *testing.T) {
+	fake := loungetest.NewServer()
+	defer fake.Close()
+	fake.AddScreen("123456789012", "scr1", "Living Room")
+	old := loungeBaseURL
+	loungeBaseURL = fake.URL
+	defer func() { loungeBaseURL = old }()
+
+	stateDir := t.TempDir()
+	mux := NewServer(t.TempDir(), "living room", stateDir, "")
+	get := func(target string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		mux.ServeHTTP(rr, httptest.NewRequest("GET", target, nil))
+		return rr
+	}
+	if rr := get("/ytcast/pair?code=000000000000"); rr.Code != 400 {
+		t.Fatalf("unknown code: expected 400, got %d; body=%s", rr.Code, rr.Body.String())
+	}
+	if rr := get("/ytcast/pair?code=123456789012"); rr.Code != 204 {
+		t.Fatalf("pair: expected 204, got %d; body=%s", rr.Code, rr.Body.String())
+	}
+	st, err := store.LoadState(filepath.Join(stateDir, "state.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var saved struct {
+		Screens []struct {
+			ID    string `json:"id"`
+			Token string `json:"lounge_token"`
+		} `json:"screens"`
+	}
+	if err := json.Unmarshal(st.Casters["youtube"], &saved); err != nil {
+		t.Fatal(err)
+	}
+	if len(saved.Screens) != 1 || saved.Screens[0].ID != "scr1" || saved.Screens[0].Token == "" {
+		t.Fatalf("screen not persisted: %s", st.Casters["youtube"])
+	}
+
+	yt := url.QueryEscape("https://www.youtube.com/watch?v=abc&t=30")
+	if rr := get("/play?type=youtube&url=" + yt); rr.Code != 204 {
+		t.Fatalf("play: expected 204, got %d; body=%s", rr.Code, rr.Body.String())
+	}
+	if rr := get("/stop?type=youtube"); rr.Code != 204 {
+		t.Fatalf("stop: expected 204, got %d; body=%s", rr.Code, rr.Body.String())
+	}
+	if rr := get("/pause?type=youtube"); rr.Code != 400 {
+		t.Fatalf("pause: expected 400, got %d", rr.Code)
+	}
+
+	// a restarted server finds the screen in state.json
+	mux = NewServer(t.TempDir(), "scr1", stateDir, "")
+	if rr := get("/queue?type=youtube&url=" + url.QueryEscape("https://youtu.be/def")); rr.Code != 204 {
+		t.Fatalf("queue after restart: expected 204, got %d; body=%s", rr.Code, rr.Body.String())
+	}
+	var got []string
+	for _, c := range fake.Commands() {
+		got = append(got, c.Name+" "+c.Params.Get("videoId")+" "+c.Params.Get("currentTime"))
+	}
+	if strings.Join(got, "|") != "setPlaylist abc 30|stopVideo  |addVideo def " {
+		t.Fatalf("unexpected lounge commands %q", got)
+	}
+	if _, err := os.Stat(filepath.Join(stateDir, "state.json.tmp")); !os.IsNotExist(err) {
+		t.Fatalf("temporary state file left behind: %v", err)
+	}
+}
@@ -0,0 +1,55 @@
+package http
+
+import (
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBrowse_RecursiveFlattensSubtree(t *testing.T) {
+	root := t.TempDir()
+	for _, it := range []struct{ dir, name string }{
+		{"Channels", "top"},
+		{"Channels/Posy/Season 1", "deep"},
+		{"Channels/Other", "side"},
+		{"Elsewhere", "outside"},
+	} {
+		base := filepath.Join(root, filepath.FromSlash(it.dir), it.name)
+		if err := os.MkdirAll(filepath.Dir(base), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(base+".strm", []byte("plugin://plugin.video.youtube/play/?video_id=abc"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(base+".nfo", []byte("<movie><title>T "+it.name+"</title><thumb>t.jpg</thumb></movie>"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	mux := NewServer(root, "", "", "")
+
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest("GET", "/Channels/?recursive=1", nil))
+	body := rr.Body.String()
+	if rr.Code != 200 {
+		t.Fatalf("expected 200, got %d", rr.Code)
+	}
+	for _, want := range []string{"T top", "T deep", "T side", `<small class="muted">Posy/Season 1</small>`, `src="/Channels/Posy/Season%201/t.jpg"`} {
+		if !strings.Contains(body, want) {
+			t.Fatalf("missing %q in body=%s", want, body)
+		}
+	}
+	if strings.Contains(body, "T outside") || strings.Contains(body, `data-kind="dir"`) {
+		t.Fatalf("expected only videos of the subtree")
+	}
+	if strings.Contains(body, `<small class="muted"></small>`) {
+		t.Fatalf("items of the folder itself should not be labelled")
+	}
+
+	rr = httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest("GET", "/Channels/", nil))
+	if strings.Contains(rr.Body.String(), "T deep") {
+		t.Fatalf("default view should not include subfolder items")
+	}
+}
@@ -0,0 +1,82 @@
+package http
+
+import (
+	"html/template"
+	nethttp "net/http"
+	"strings"
+	"unicode"
+
+	"github.com/claes/ytplv/internal/library"
+)
+
+// handleSearch renders the videos matching q across the whole library in
+// the browse list, ranked and paginated like a folder.
+func (s *server) handleSearch(w nethttp.ResponseWriter, r *nethttp.Request) {
+	q := strings.TrimSpace(r.URL.Query().Get("q"))
+	results := s.lib.Search(q)
+
+	page := currentPage(r)
+	start, end, hasPrev, hasNext := pageBounds(page, len(results))
+	prevURL, nextURL := pageLinks(r.URL.Query(), "/search", page, hasPrev, hasNext)
+	data := browsePageData{
+		Page:        page,
+		HasPrev:     hasPrev,
+		HasNext:     hasNext,
+		PrevURL:     prevURL,
+		NextURL:     nextURL,
+		Entries:     results[start:end],
+		Breadcrumbs: []breadcrumb{{Name: "Root", Href: "/"}, {Name: "Search", Current: true}},
+		Query:       q,
+		Terms:       library.Terms(q),
+		Virtual:     true,
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_ = s.tpl.Execute(w, data)
+}
+
+// templateHighlight escapes text and wraps the words starting with one of
+// terms in <mark>, matching how Search matches prefixes.
+func templateHighlight(text string, terms []string) template.HTML {
+	if len(terms) == 0 {
+		return template.HTML(template.HTMLEscapeString(text))
+	}
+	var b strings.Builder
+	rest := text
+	for rest != "" {
+		// split off the next word and the separator run before it
+		i := strings.IndexFunc(rest, isWordRune)
+		if i < 0 {
+			b.WriteString(template.HTMLEscapeString(rest))
+			break
+		}
+		b.WriteString(template.HTMLEscapeString(rest[:i]))
+		rest = rest[i:]
+		j := strings.IndexFunc(rest, func(r rune) bool { return !isWordRune(r) })
+		if j < 0 {
+			j = len(rest)
+		}
+		word := rest[:j]
+		rest = rest[j:]
+		if matchesTerm(word, terms) {
+			b.WriteString("<mark>" + template.HTMLEscapeString(word) + "</mark>")
+		} else {
+			b.WriteString(template.HTMLEscapeString(word))
+		}
+	}
+	return template.HTML(b.String())
+}
+
+// isWordRune reports whether r is part of a word, as split by library.Terms.
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+func matchesTerm(word string, terms []string) bool {
+	w := strings.ToLower(word)
+	for _, t := range terms {
+		if strings.HasPrefix(w, t) {
+			return true
+		}
+	}
+	return false
+}
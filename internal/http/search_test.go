@@ -0,0 +1,70 @@
+package http
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSearch_RendersRankedResults(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "Posy")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for i, title := range []string{"Strange Filters", "Filter <Sweeps>"} {
+		base := filepath.Join(dir, string(rune('a'+i)))
+		if err := os.WriteFile(base+".strm", []byte("plugin://plugin.video.youtube/play/?video_id=abc"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		nfo := "<movie><title>" + strings.NewReplacer("<", "&lt;", ">", "&gt;").Replace(title) + "</title><thumb>t.jpg</thumb></movie>"
+		if err := os.WriteFile(base+".nfo", []byte(nfo), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	mux := NewServer(root, "", "", "")
+
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest("GET", "/search?q=filter", nil))
+	if rr.Code != 200 {
+		t.Fatalf("expected 200, got %d", rr.Code)
+	}
+	body := rr.Body.String()
+	if !strings.Contains(body, "<mark>Filter</mark> &lt;Sweeps&gt;") || !strings.Contains(body, "Strange <mark>Filters</mark>") {
+		t.Fatalf("expected highlighted, escaped titles; body=%s", body)
+	}
+	if strings.Index(body, "Filter</mark> &lt;Sweeps") > strings.Index(body, "<mark>Filters</mark>") {
+		t.Fatalf("expected whole-word match first")
+	}
+	if !strings.Contains(body, `<small class="muted">Posy</small>`) || !strings.Contains(body, `src="/Posy/t.jpg"`) {
+		t.Fatalf("expected folder label and folder-relative thumb; body=%s", body)
+	}
+
+	rr = httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest("GET", "/search?q=nothing", nil))
+	if !strings.Contains(rr.Body.String(), "No matches for") {
+		t.Fatalf("expected empty-state message")
+	}
+}
+
+func TestSearch_PageLinksKeepQuery(t *testing.T) {
+	root := t.TempDir()
+	for i := 0; i < browsePageSize+5; i++ {
+		base := filepath.Join(root, fmt.Sprintf("v%03d", i))
+		if err := os.WriteFile(base+".strm", []byte("plugin://plugin.video.youtube/play/?video_id=abc"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(base+".nfo", []byte("<movie><title>clip</title></movie>"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	mux := NewServer(root, "", "", "")
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest("GET", "/search?q=clip", nil))
+	if !strings.Contains(rr.Body.String(), `data-href="/search?page=2&amp;q=clip"`) {
+		t.Fatalf("expected next link to keep query; body=%s", rr.Body.String())
+	}
+}
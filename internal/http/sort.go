@@ -0,0 +1,80 @@
+package http
+
+import (
+	nethttp "net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/claes/ytplv/internal/browse"
+)
+
+// Cookies holding the visitor's default listing order, set whenever a
+// request picks an order explicitly.
+const (
+	sortCookie    = "castweb_sort"
+	foldersCookie = "castweb_folders"
+	prefMaxAge    = 365 * 24 * 60 * 60
+)
+
+// sortPrefs is how a listing is ordered for a request.
+type sortPrefs struct {
+	Order        browse.SortOrder
+	FoldersFirst bool
+	Seed         int64 // for browse.SortRandom
+}
+
+// sortPrefsFromRequest reads the order from the sort= and folders= query
+// parameters, falling back to the folder's .castweb.json (cfg), the
+// visitor's cookies and then to date order with folders mixed in. Explicit
+// parameters are remembered in cookies. The returned query is the request's,
+// plus the random seed when one had to be picked, so that pagination links
+// keep the same order.
+func sortPrefsFromRequest(w nethttp.ResponseWriter, r *nethttp.Request, cfg browse.DirConfig) (sortPrefs, url.Values) {
+	query := r.URL.Query()
+	prefs := sortPrefs{Order: browse.SortDate}
+	if c, err := r.Cookie(sortCookie); err == nil {
+		if o, ok := browse.ParseSortOrder(c.Value); ok {
+			prefs.Order = o
+		}
+	}
+	if c, err := r.Cookie(foldersCookie); err == nil {
+		prefs.FoldersFirst = c.Value == "first"
+	}
+	if o, ok := browse.ParseSortOrder(cfg.Sort); ok {
+		prefs.Order = o
+	}
+	if cfg.FoldersFirst != nil {
+		prefs.FoldersFirst = *cfg.FoldersFirst
+	}
+	if o, ok := browse.ParseSortOrder(query.Get("sort")); ok {
+		prefs.Order = o
+		setPref(w, sortCookie, string(o))
+	}
+	switch f := query.Get("folders"); f {
+	case "first", "mixed":
+		prefs.FoldersFirst = f == "first"
+		setPref(w, foldersCookie, f)
+	}
+	if prefs.Order == browse.SortRandom {
+		seed, err := strconv.ParseInt(query.Get("seed"), 10, 64)
+		if err != nil {
+			seed = time.Now().UnixNano()
+			query = cloneValues(query)
+			query.Set("seed", strconv.FormatInt(seed, 10))
+		}
+		prefs.Seed = seed
+	}
+	return prefs, query
+}
+
+func setPref(w nethttp.ResponseWriter, name, value string) {
+	nethttp.SetCookie(w, &nethttp.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     "/",
+		MaxAge:   prefMaxAge,
+		HttpOnly: true,
+		SameSite: nethttp.SameSiteLaxMode,
+	})
+}
@@ -0,0 +1,83 @@
+package http
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func writeSortItems(t *testing.T, root string, n int) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		base := filepath.Join(root, fmt.Sprintf("v%03d", i))
+		if err := os.WriteFile(base+".strm", []byte("plugin://plugin.video.youtube/play/?video_id=abc"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(base+".nfo", []byte(fmt.Sprintf("<movie><title>Title %d</title></movie>", i)), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(root, "zfolder"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBrowse_SortParamAndCookie(t *testing.T) {
+	root := t.TempDir()
+	writeSortItems(t, root, 3)
+	mux := NewServer(root, "", "", "")
+
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest("GET", "/?sort=title&folders=first", nil))
+	body := rr.Body.String()
+	if i, j := strings.Index(body, `data-path="zfolder"`), strings.Index(body, "Title 0"); i < 0 || j < 0 || i > j {
+		t.Fatalf("expected folder before videos")
+	}
+	if i, j := strings.Index(body, ">Title 0<"), strings.Index(body, ">Title 2<"); i > j {
+		t.Fatalf("expected title order")
+	}
+	cookies := rr.Result().Cookies()
+	if len(cookies) != 2 {
+		t.Fatalf("expected sort and folders cookies, got %v", cookies)
+	}
+
+	// the cookies become the default for later requests
+	req := httptest.NewRequest("GET", "/", nil)
+	for _, c := range cookies {
+		req.AddCookie(c)
+	}
+	rr = httptest.NewRecorder()
+	mux.ServeHTTP(rr, req)
+	if !strings.Contains(rr.Body.String(), `<option value="title" selected>`) {
+		t.Fatalf("expected title order from cookie")
+	}
+	if len(rr.Result().Cookies()) != 0 {
+		t.Fatalf("cookies should only be set by explicit parameters")
+	}
+}
+
+func TestBrowse_RandomSortPaginationKeepsSeed(t *testing.T) {
+	root := t.TempDir()
+	writeSortItems(t, root, browsePageSize+1)
+	mux := NewServer(root, "", "", "")
+
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest("GET", "/?sort=random&folders=mixed", nil))
+	m := regexp.MustCompile(`data-href="/\?folders=mixed&amp;page=2&amp;seed=(\d+)&amp;sort=random"`).FindStringSubmatch(rr.Body.String())
+	if m == nil {
+		t.Fatalf("expected next link with sort, folders and seed; body=%s", rr.Body.String())
+	}
+	first := func(url string) string {
+		rr := httptest.NewRecorder()
+		mux.ServeHTTP(rr, httptest.NewRequest("GET", url, nil))
+		return regexp.MustCompile(`data-title="([^"]+)"`).FindStringSubmatch(rr.Body.String())[1]
+	}
+	url := "/?sort=random&seed=" + m[1]
+	if first(url) != first(url) {
+		t.Fatalf("same seed should give the same order")
+	}
+}
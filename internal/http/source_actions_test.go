@@ -0,0 +1,69 @@
+package http
+
+import (
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/claes/ytplv/internal/model"
+)
+
+func TestPlayQueue_RejectUnsupportedActions(t *testing.T) {
+	mux := NewServer(t.TempDir(), "dev", "", "")
+	cases := []struct {
+		path string
+		want int
+	}{
+		{"/queue?type=svtplay&url=" + url.QueryEscape("https://www.svtplay.se/video/x"), 400},
+		{"/play?type=vimeo&url=" + url.QueryEscape("https://vimeo.com/1"), 400},
+		{"/play?type=nope&url=" + url.QueryEscape("https://example.com/"), 400},
+		{"/play?url=" + url.QueryEscape("https://example.com/video.mp4"), 400},
+	}
+	for _, c := range cases {
+		rr := httptest.NewRecorder()
+		mux.ServeHTTP(rr, httptest.NewRequest("GET", c.path, nil))
+		if rr.Code != c.want {
+			t.Fatalf("%s: expected %d, got %d; body=%s", c.path, c.want, rr.Code, rr.Body.String())
+		}
+	}
+}
+
+func TestPlaylist_RequiresVideoIDs(t *testing.T) {
+	mux := NewServer(t.TempDir(), "dev", "", "")
+	u := url.QueryEscape("https://www.youtube.com/playlist?list=PLx")
+	for _, path := range []string{
+		"/play?type=youtube-playlist&url=" + u,
+		"/queue?type=youtube-playlist&url=" + u + "&ids=" + url.QueryEscape("a;rm -rf"),
+	} {
+		rr := httptest.NewRecorder()
+		mux.ServeHTTP(rr, httptest.NewRequest("GET", path, nil))
+		if rr.Code != 400 {
+			t.Fatalf("%s: expected 400, got %d", path, rr.Code)
+		}
+	}
+}
+
+func TestPlay_InvalidStart(t *testing.T) {
+	mux := NewServer(t.TempDir(), "dev", "", "")
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest("GET", "/play?url="+url.QueryEscape("https://youtu.be/abc")+"&start=-5", nil))
+	if rr.Code != 400 {
+		t.Fatalf("expected 400 for negative start, got %d", rr.Code)
+	}
+}
+
+func TestTemplateActions_PlaylistWithoutVideos(t *testing.T) {
+	for _, tc := range []struct {
+		v    model.Video
+		want string
+	}{
+		{model.Video{Type: "youtube-playlist", VideoID: "PLx"}, ""},
+		{model.Video{Type: "youtube-playlist", VideoID: "channel/UCx"}, ""},
+		{model.Video{Type: "youtube-playlist", VideoID: "PLx", VideoIDs: []string{"a"}}, "play queue open"},
+		{model.Video{Type: "youtube", VideoID: "abc"}, "play queue open"},
+	} {
+		if got := templateActions(&tc.v); got != tc.want {
+			t.Errorf("%+v: got %q, want %q", tc.v, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package http
+
+import (
+	"fmt"
+	nethttp "net/http"
+	"net/url"
+	"strings"
+
+	"github.com/claes/ytplv/internal/model"
+)
+
+// tagsPath is the URL prefix of the virtual tag folders. It shadows a
+// library folder of the same name.
+const tagsPath = "tags"
+
+// handleTags serves /tags/, a virtual folder per tag with its item count,
+// and /tags/<tag>/, every video carrying the tag across the library.
+func (s *server) handleTags(w nethttp.ResponseWriter, r *nethttp.Request) {
+	tag := strings.Trim(strings.TrimPrefix(r.URL.Path, "/"+tagsPath+"/"), "/")
+	var entries []model.Entry
+	crumbs := []breadcrumb{{Name: "Root", Href: "/"}, {Name: "Tags", Href: "/" + tagsPath + "/", Current: tag == ""}}
+	path := tagsPath
+	parent := ""
+	if tag == "" {
+		for _, tc := range s.lib.Tags() {
+			entries = append(entries, model.Entry{
+				Kind: "dir",
+				Name: tc.Tag,
+				Path: tagsPath + "/" + tc.Tag,
+				Folder: &model.Folder{
+					Title: fmt.Sprintf("%s (%d)", tc.Tag, tc.Count),
+				},
+			})
+		}
+	} else {
+		entries = s.lib.Tagged(tag)
+		if entries == nil {
+			httpError(w, nethttp.StatusNotFound, "unknown tag")
+			return
+		}
+		crumbs = append(crumbs, breadcrumb{Name: tag, Current: true})
+		path = tagsPath + "/" + tag
+		parent = tagsPath
+	}
+
+	page := currentPage(r)
+	start, end, hasPrev, hasNext := pageBounds(page, len(entries))
+	prevURL, nextURL := pageLinks(r.URL.Query(), tagURL(tag), page, hasPrev, hasNext)
+	data := browsePageData{
+		Page:        page,
+		HasPrev:     hasPrev,
+		HasNext:     hasNext,
+		PrevURL:     prevURL,
+		NextURL:     nextURL,
+		Path:        path,
+		ParentPath:  parent,
+		Entries:     entries[start:end],
+		Breadcrumbs: crumbs,
+		Virtual:     true,
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_ = s.tpl.Execute(w, data)
+}
+
+// tagURL returns the URL of the tag's virtual folder, or of the tag list
+// when tag is empty.
+func tagURL(tag string) string {
+	if tag == "" {
+		return "/" + tagsPath + "/"
+	}
+	return "/" + tagsPath + "/" + url.PathEscape(tag) + "/"
+}
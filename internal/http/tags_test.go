@@ -0,0 +1,55 @@
+package http
+
+import (
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTags_VirtualFolders(t *testing.T) {
+	root := t.TempDir()
+	for _, it := range []struct{ dir, name, tags string }{
+		{"Posy", "a", "<tag>Synth</tag><tag>AC/DC</tag>"},
+		{"Other", "b", "<tag>synth</tag>"},
+	} {
+		base := filepath.Join(root, it.dir, it.name)
+		if err := os.MkdirAll(filepath.Dir(base), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(base+".strm", []byte("plugin://plugin.video.youtube/play/?video_id=abc"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(base+".nfo", []byte("<movie><title>T "+it.name+"</title>"+it.tags+"</movie>"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	mux := NewServer(root, "", "", "")
+
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest("GET", "/tags/", nil))
+	body := rr.Body.String()
+	if rr.Code != 200 || !strings.Contains(strings.ToLower(body), "synth (2)") || !strings.Contains(body, `data-path="tags/AC/DC"`) {
+		t.Fatalf("expected tag folders with counts; code=%d body=%s", rr.Code, body)
+	}
+
+	rr = httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest("GET", "/tags/synth/", nil))
+	body = rr.Body.String()
+	if rr.Code != 200 || !strings.Contains(body, "T a") || !strings.Contains(body, "T b") || !strings.Contains(body, `<small class="muted">Other</small>`) {
+		t.Fatalf("expected tagged videos from both folders; code=%d body=%s", rr.Code, body)
+	}
+
+	rr = httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest("GET", "/tags/AC%2FDC/", nil))
+	if rr.Code != 200 || !strings.Contains(rr.Body.String(), "T a") {
+		t.Fatalf("expected tag with slash to resolve; code=%d", rr.Code)
+	}
+
+	rr = httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest("GET", "/tags/nope/", nil))
+	if rr.Code != 404 {
+		t.Fatalf("expected 404 for unknown tag, got %d", rr.Code)
+	}
+}
@@ -4,8 +4,13 @@ import (
 	"embed"
 	"html/template"
 	"net/url"
+	"strconv"
 	"strings"
 	"time"
+
+	"github.com/claes/ytplv/internal/browse"
+	"github.com/claes/ytplv/internal/model"
+	"github.com/claes/ytplv/internal/source"
 )
 
 //go:embed templates/browse.html templates/pair.html
@@ -34,7 +39,68 @@ func pageTemplateFuncs() template.FuncMap {
 			return a + "/" + b
 		},
 		"urlfor": templateURLFor,
+		"rating": templateRating,
+		"playurl": func(v *model.Video) string {
+			_, u := source.Resolve(v.Type, v.VideoID, v.URL)
+			return source.WithStart(u, v.Start)
+		},
+		"actions": templateActions,
+		"hl":      templateHighlight,
+		"relto":   templateRelTo,
+		"isimage": browse.IsImageRef,
+	}
+}
+
+// templateRelTo returns path relative to the folder base, or path unchanged
+// when it is not inside base.
+func templateRelTo(base, path string) string {
+	if base == "" {
+		return path
+	}
+	if path == base {
+		return ""
+	}
+	if rest, ok := strings.CutPrefix(path, base+"/"); ok {
+		return rest
+	}
+	return path
+}
+
+// templateActions returns the space-separated actions the item's source
+// supports. A playlist or channel without known video ids gets none, as
+// /play and /queue would refuse it.
+func templateActions(v *model.Video) string {
+	if v.Type == source.YouTubePlaylist.Name() && len(v.VideoIDs) == 0 {
+		return ""
+	}
+	s, _ := source.Resolve(v.Type, v.VideoID, v.URL)
+	if s == nil {
+		return ""
+	}
+	out := make([]string, 0, len(s.Actions()))
+	for _, a := range s.Actions() {
+		out = append(out, string(a))
+	}
+	return strings.Join(out, " ")
+}
+
+// templateRating formats the default rating (or the first one) as "7.5/10".
+func templateRating(ratings []model.Rating) string {
+	if len(ratings) == 0 {
+		return ""
+	}
+	r := ratings[0]
+	for _, c := range ratings {
+		if c.Default {
+			r = c
+			break
+		}
+	}
+	out := strconv.FormatFloat(r.Value, 'f', -1, 64)
+	if r.Max > 0 {
+		out += "/" + strconv.FormatFloat(r.Max, 'f', -1, 64)
 	}
+	return out
 }
 
 func templateURLFor(base, name string) string {
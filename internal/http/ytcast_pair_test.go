@@ -3,13 +3,31 @@
 package http
 
 import (
+    nethttp "net/http"
     "net/http/httptest"
     "os"
     "path/filepath"
     "runtime"
     "testing"
+
+    "github.com/claes/ytplv/internal/cast"
 )
 
+// newYtcastServer is NewServer with the ytcast command, rather than the
+// built-in lounge client, casting YouTube items.
+func newYtcastServer(t *testing.T, root, device string) nethttp.Handler {
+    t.Helper()
+    yt := cast.NewYtcast("ytcast", "")
+    router, err := cast.NewRouter([]cast.Caster{yt}, []cast.Route{
+        {Source: "youtube", Caster: yt.Name()},
+        {Source: "youtube-playlist", Caster: yt.Name()},
+    })
+    if err != nil {
+        t.Fatal(err)
+    }
+    return New(Config{Root: root, YtcastDevice: device, Casters: router})
+}
+
 // createFakeYtcast creates a temporary executable named "ytcast" that records
 // its arguments to a file and exits 0.
 func createFakeYtcast(t *testing.T, dir string) string {
@@ -35,7 +53,7 @@ func createFakeYtcast(t *testing.T, dir string) string {
 }
 
 func TestYtcastPair_Validation(t *testing.T) {
-    mux := NewServer(t.TempDir(), "", "", "")
+    mux := newYtcastServer(t, t.TempDir(), "")
 
     // missing code
     rr := httptest.NewRecorder()
@@ -68,7 +86,7 @@ func TestYtcastPair_Executes(t *testing.T) {
     _ = createFakeYtcast(t, tmp)
     t.Setenv("PATH", tmp+string(os.PathListSeparator)+os.Getenv("PATH"))
 
-    mux := NewServer(t.TempDir(), "", "", "")
+    mux := newYtcastServer(t, t.TempDir(), "")
 
     rr := httptest.NewRecorder()
     req := httptest.NewRequest("GET", "/ytcast/pair?code=123456789012", nil)
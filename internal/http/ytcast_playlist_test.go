@@ -0,0 +1,48 @@
+//go:build integration
+
+package http
+
+import (
+    "net/http/httptest"
+    "os"
+    "path/filepath"
+    "runtime"
+    "strings"
+    "testing"
+)
+
+func TestPlaylist_PlaysFirstQueuesRest(t *testing.T) {
+    if runtime.GOOS == "windows" {
+        t.Skip("tracer script is POSIX sh")
+    }
+    tmp := t.TempDir()
+    trace := filepath.Join(tmp, "trace.txt")
+    exe := filepath.Join(tmp, "ytcast")
+    script := "#!/bin/sh\nprintf '%s\\n' \"$*\" >> \"$TRACE_PATH\"\n"
+    if err := os.WriteFile(exe, []byte(script), 0o755); err != nil {
+        t.Fatal(err)
+    }
+    t.Setenv("PATH", tmp+string(os.PathListSeparator)+os.Getenv("PATH"))
+    t.Setenv("TRACE_PATH", trace)
+
+    mux := newYtcastServer(t, t.TempDir(), "dev")
+    rr := httptest.NewRecorder()
+    req := httptest.NewRequest("GET", "/play?type=youtube-playlist&url=https://www.youtube.com/playlist?list=PLx&ids=a1,b2,c3", nil)
+    mux.ServeHTTP(rr, req)
+    if rr.Code != 204 {
+        t.Fatalf("expected 204, got %d; body=%s", rr.Code, rr.Body.String())
+    }
+    data, err := os.ReadFile(trace)
+    if err != nil {
+        t.Fatal(err)
+    }
+    lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+    want := []string{
+        "-d dev https://www.youtube.com/watch?v=a1",
+        "-d dev -a https://www.youtube.com/watch?v=b2",
+        "-d dev -a https://www.youtube.com/watch?v=c3",
+    }
+    if strings.Join(lines, "|") != strings.Join(want, "|") {
+        t.Fatalf("unexpected ytcast calls:\n%s", data)
+    }
+}
@@ -42,7 +42,7 @@ func createTracingYtcast(t *testing.T, dir string) string {
 }
 
 func TestYtcastSetCode_Validation(t *testing.T) {
-    mux := NewServer(t.TempDir(), "", "", "")
+    mux := newYtcastServer(t, t.TempDir(), "")
 
     // missing
     rr := httptest.NewRecorder()
@@ -67,7 +67,7 @@ func TestYtcastSetCode_AppliesToPlay(t *testing.T) {
     t.Setenv("PATH", tmp+string(os.PathListSeparator)+os.Getenv("PATH"))
     t.Setenv("TRACE_PATH", trace)
 
-    mux := NewServer(t.TempDir(), "", "", "")
+    mux := newYtcastServer(t, t.TempDir(), "")
 
     // Set the code
     rr := httptest.NewRecorder()
@@ -98,3 +98,28 @@ func TestYtcastSetCode_AppliesToPlay(t *testing.T) {
         t.Fatalf("expected args to include the URL, got %q", got)
     }
 }
+
+func TestPlay_PassesStartOffset(t *testing.T) {
+    tmp := t.TempDir()
+    trace := filepath.Join(tmp, "trace.txt")
+    _ = createTracingYtcast(t, tmp)
+    t.Setenv("PATH", tmp+string(os.PathListSeparator)+os.Getenv("PATH"))
+    t.Setenv("TRACE_PATH", trace)
+
+    mux := newYtcastServer(t, t.TempDir(), "dev")
+    for _, path := range []string{"/play", "/queue"} {
+        rr := httptest.NewRecorder()
+        req := httptest.NewRequest("GET", path+"?type=youtube&url=https://www.youtube.com/watch?v=abc123&start=90", nil)
+        mux.ServeHTTP(rr, req)
+        if rr.Code != 204 {
+            t.Fatalf("%s: expected 204, got %d; body=%s", path, rr.Code, rr.Body.String())
+        }
+        data, err := os.ReadFile(trace)
+        if err != nil {
+            t.Fatalf("reading trace: %v", err)
+        }
+        if !strings.Contains(string(data), "t=90") {
+            t.Fatalf("%s: expected URL with t=90, got %q", path, data)
+        }
+    }
+}
@@ -0,0 +1,199 @@
+// Package library keeps an in-memory index of directory listings under the
+// root, so browsing does not re-read and re-parse the tree on every request.
+package library
+
+import (
+	"errors"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/claes/ytplv/internal/browse"
+	"github.com/claes/ytplv/internal/model"
+)
+
+// Index caches listings per directory and parsed items per file.
+//
+// Listings are only cached while a filesystem watcher is running (see Watch),
+// since nothing else would tell the index that a directory changed. Without
+// one, every Listing call rescans the directory but still reuses parsed items
+// whose files have the same mtime and size.
+type Index struct {
+	root  string
+	items *browse.ItemCache
+
+	mu       sync.RWMutex
+	listings map[string]model.Listing // rel dir -> listing
+	gen      uint64                   // bumped on every invalidation
+	watcher  watcher
+
+	search    *searchIndex // built lazily by Search
+	searchGen uint64       // gen the search index was built at
+
+	videos    []model.Entry // last walk of Videos
+	videosGen uint64        // gen videos was walked at
+	videosAt  time.Time     // when videos was walked, zero if never
+}
+
+// NewIndex returns an empty index for root. Call Watch to enable listing
+// caching with change notifications.
+func NewIndex(root string) *Index {
+	return &Index{
+		root:     root,
+		items:    browse.NewItemCache(),
+		listings: make(map[string]model.Listing),
+	}
+}
+
+// Root returns the library root directory.
+func (ix *Index) Root() string { return ix.root }
+
+// Watch starts watching the tree for changes. It returns an error when the
+// platform has no watcher or the watch could not be set up; the index then
+// keeps working without caching listings.
+func (ix *Index) Watch() error {
+	w, err := startWatcher(ix.root, ix.changed)
+	if err != nil {
+		return err
+	}
+	ix.mu.Lock()
+	ix.watcher = w
+	ix.listings = make(map[string]model.Listing)
+	ix.gen++
+	ix.mu.Unlock()
+	return nil
+}
+
+// Close stops the watcher, if any.
+func (ix *Index) Close() error {
+	ix.mu.Lock()
+	w := ix.watcher
+	ix.watcher = nil
+	ix.listings = make(map[string]model.Listing)
+	ix.mu.Unlock()
+	if w == nil {
+		return nil
+	}
+	return w.Close()
+}
+
+// Listing returns the listing of rel, from memory when it is cached.
+// The returned slices are copies and may be modified by the caller.
+func (ix *Index) Listing(rel string) (model.Listing, error) {
+	rel = cleanRel(rel)
+	ix.mu.RLock()
+	l, ok := ix.listings[rel]
+	watching := ix.watcher != nil
+	gen := ix.gen
+	ix.mu.RUnlock()
+	if ok {
+		return copyListing(l), nil
+	}
+	l, err := browse.BuildListingCached(ix.root, rel, ix.items)
+	if err != nil {
+		return l, err
+	}
+	if watching {
+		ix.mu.Lock()
+		// a change seen while scanning may not be reflected in l; keep it
+		// uncached so the next request rescans
+		if ix.watcher != nil && ix.gen == gen {
+			ix.listings[rel] = l
+		}
+		ix.mu.Unlock()
+	}
+	return copyListing(l), nil
+}
+
+// Invalidate drops the cached listing of rel and of its parent, whose entry
+// for rel (folder date, metadata) may change with it.
+func (ix *Index) Invalidate(rel string) {
+	rel = cleanRel(rel)
+	ix.mu.Lock()
+	ix.gen++
+	delete(ix.listings, rel)
+	if rel != "" {
+		delete(ix.listings, parentOf(rel))
+	}
+	ix.mu.Unlock()
+}
+
+// invalidateTree drops rel and every cached listing below it. When the
+// folder is gone, the parsed items in and below it are dropped too, as no
+// listing will clean them up.
+func (ix *Index) invalidateTree(rel string) {
+	rel = cleanRel(rel)
+	ix.mu.Lock()
+	ix.gen++
+	for k := range ix.listings {
+		if rel == "" || k == rel || strings.HasPrefix(k, rel+"/") {
+			delete(ix.listings, k)
+		}
+	}
+	ix.mu.Unlock()
+	dir := filepath.Join(ix.root, filepath.FromSlash(rel))
+	if _, err := os.Stat(dir); errors.Is(err, fs.ErrNotExist) {
+		ix.items.DropDir(dir)
+	}
+	ix.Invalidate(rel)
+}
+
+// changed is the watcher callback for a change of name inside dir.
+func (ix *Index) changed(dir, name string, isDir bool) {
+	rel, err := filepath.Rel(ix.root, dir)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return
+	}
+	rel = filepath.ToSlash(rel)
+	slog.Debug("library change", "dir", rel, "name", name, "is_dir", isDir)
+	if isDir && name != "" {
+		ix.invalidateTree(joinRel(rel, name))
+	}
+	if name == browse.IgnoreFileName {
+		// ignore rules apply to every folder below
+		ix.invalidateTree(rel)
+	}
+	ix.Invalidate(rel)
+}
+
+// errNoWatcher is returned by Watch on platforms without a watcher.
+var errNoWatcher = errors.New("filesystem watching not supported on this platform")
+
+// watcher delivers change notifications until closed.
+type watcher interface {
+	Close() error
+}
+
+func copyListing(l model.Listing) model.Listing {
+	l.Dirs = append([]string(nil), l.Dirs...)
+	l.Videos = append([]model.Video(nil), l.Videos...)
+	l.Entries = append([]model.Entry(nil), l.Entries...)
+	return l
+}
+
+func cleanRel(rel string) string {
+	rel = filepath.ToSlash(filepath.Clean(rel))
+	rel = strings.TrimPrefix(rel, "/")
+	if rel == "." {
+		return ""
+	}
+	return rel
+}
+
+func parentOf(rel string) string {
+	if i := strings.LastIndexByte(rel, '/'); i >= 0 {
+		return rel[:i]
+	}
+	return ""
+}
+
+func joinRel(a, b string) string {
+	if a == "" || a == "." {
+		return b
+	}
+	return a + "/" + b
+}
@@ -0,0 +1,141 @@
+package library
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func write(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func writeItem(t *testing.T, dir, name, id string) {
+	t.Helper()
+	write(t, filepath.Join(dir, name+".strm"), "plugin://plugin.video.youtube/play/?video_id="+id)
+	write(t, filepath.Join(dir, name+".nfo"), "<movie><title>"+name+"</title></movie>")
+}
+
+func TestIndex_UnwatchedRescans(t *testing.T) {
+	root := t.TempDir()
+	writeItem(t, filepath.Join(root, "a"), "one", "id1")
+	ix := NewIndex(root)
+
+	l, err := ix.Listing("a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(l.Videos) != 1 {
+		t.Fatalf("expected 1 video, got %d", len(l.Videos))
+	}
+	writeItem(t, filepath.Join(root, "a"), "two", "id2")
+	l, _ = ix.Listing("a")
+	if len(l.Videos) != 2 {
+		t.Fatalf("expected rescan to find 2 videos, got %d", len(l.Videos))
+	}
+	if ix.items.Len() != 2 {
+		t.Fatalf("expected 2 cached items, got %d", ix.items.Len())
+	}
+}
+
+func TestIndex_VideosReusedUntilInvalidated(t *testing.T) {
+	root := t.TempDir()
+	writeItem(t, filepath.Join(root, "a"), "one", "id1")
+	ix := NewIndex(root)
+
+	if n := len(ix.Videos()); n != 1 {
+		t.Fatalf("expected 1 video, got %d", n)
+	}
+	writeItem(t, filepath.Join(root, "a"), "two", "id2")
+	if n := len(ix.Videos()); n != 1 {
+		t.Fatalf("expected the walk to be reused, got %d videos", n)
+	}
+	ix.Invalidate("a")
+	if n := len(ix.Videos()); n != 2 {
+		t.Fatalf("expected a new walk after invalidation, got %d videos", n)
+	}
+}
+
+func TestIndex_DropsItemsOfRemovedFolders(t *testing.T) {
+	root := t.TempDir()
+	writeItem(t, filepath.Join(root, "keep"), "one", "id1")
+	writeItem(t, filepath.Join(root, "gone", "sub"), "two", "id2")
+	ix := NewIndex(root)
+	if _, err := ix.Warm(); err != nil {
+		t.Fatal(err)
+	}
+	if ix.items.Len() != 2 {
+		t.Fatalf("expected 2 cached items, got %d", ix.items.Len())
+	}
+
+	ix.changed(root, "keep", true) // still there: items are kept
+	if err := os.RemoveAll(filepath.Join(root, "gone")); err != nil {
+		t.Fatal(err)
+	}
+	ix.changed(root, "gone", true)
+	if ix.items.Len() != 1 {
+		t.Fatalf("expected items of the removed folder to be dropped, got %d", ix.items.Len())
+	}
+}
+
+func TestIndex_WatchInvalidates(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("no watcher on this platform")
+	}
+	root := t.TempDir()
+	writeItem(t, filepath.Join(root, "a"), "one", "id1")
+	ix := NewIndex(root)
+	if err := ix.Watch(); err != nil {
+		t.Fatal(err)
+	}
+	defer ix.Close()
+
+	if l, _ := ix.Listing("a"); len(l.Videos) != 1 {
+		t.Fatalf("expected 1 video, got %d", len(l.Videos))
+	}
+	if _, err := ix.Listing(""); err != nil {
+		t.Fatal(err)
+	}
+	ix.mu.RLock()
+	cached := len(ix.listings)
+	ix.mu.RUnlock()
+	if cached != 2 {
+		t.Fatalf("expected 2 cached listings, got %d", cached)
+	}
+
+	// a new subdirectory with content must be picked up as well
+	writeItem(t, filepath.Join(root, "a"), "two", "id2")
+	writeItem(t, filepath.Join(root, "a", "b"), "three", "id3")
+	deadline := time.Now().Add(3 * time.Second)
+	for {
+		l, _ := ix.Listing("a")
+		sub, _ := ix.Listing("a/b")
+		if len(l.Videos) == 2 && len(sub.Videos) == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("listing not invalidated: %d videos in a, %d in a/b", len(l.Videos), len(sub.Videos))
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	// edits inside the new subdirectory are seen through its own watch
+	writeItem(t, filepath.Join(root, "a", "b"), "four", "id4")
+	for {
+		sub, _ := ix.Listing("a/b")
+		if len(sub.Videos) == 2 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("new subdirectory not watched")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
@@ -0,0 +1,91 @@
+package library
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/claes/ytplv/internal/browse"
+)
+
+// IndexFile is the name of the saved index in the state directory.
+const IndexFile = "library.json"
+
+// indexVersion is bumped whenever the saved item format changes; files of
+// another version are ignored.
+const indexVersion = 2
+
+type indexFile struct {
+	Version int               `json:"version"`
+	Root    string            `json:"root"`
+	Items   *browse.ItemCache `json:"items"`
+}
+
+// Load reads parsed items saved by Save. A missing file, or one saved for a
+// different root or format, leaves the index empty without error. Loaded
+// items are only reused while their files keep the same mtime and size.
+// Call Load before the index is used.
+func (ix *Index) Load(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return fmt.Errorf("read index: %w", err)
+	}
+	f := indexFile{Items: browse.NewItemCache()}
+	if err := json.Unmarshal(data, &f); err != nil {
+		return fmt.Errorf("decode index: %w", err)
+	}
+	if f.Version != indexVersion || f.Root != ix.root {
+		return nil
+	}
+	ix.items = f.Items
+	return nil
+}
+
+// Save writes the parsed items to path atomically.
+func (ix *Index) Save(path string) error {
+	data, err := json.Marshal(indexFile{Version: indexVersion, Root: ix.root, Items: ix.items})
+	if err != nil {
+		return fmt.Errorf("encode index: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("write tmp: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("rename tmp: %w", err)
+	}
+	return nil
+}
+
+// Warm scans every directory under the root that browsing reaches, folder
+// by folder through the listings as VideosUnder does, so hidden and ignored
+// folders are skipped. Only items whose files changed since they were cached
+// are re-parsed, and items of directories not reached are forgotten. It
+// returns the number of directories scanned.
+func (ix *Index) Warm() (int, error) {
+	dirs := make(map[string]bool)
+	var walk func(rel string) error
+	walk = func(rel string) error {
+		l, err := ix.Listing(rel)
+		if err != nil {
+			return err
+		}
+		dirs[filepath.Join(ix.root, rel)] = true
+		for _, d := range l.Dirs {
+			_ = walk(joinRel(l.Path, d)) // unreadable folders are reported by lint, not here
+		}
+		return nil
+	}
+	if err := walk(""); err != nil {
+		return 0, err
+	}
+	ix.items.RetainDirs(dirs)
+	return len(dirs), nil
+}
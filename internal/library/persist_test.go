@@ -0,0 +1,107 @@
+package library
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/claes/ytplv/internal/browse"
+)
+
+func TestIndex_SaveLoadReparsesOnlyChanged(t *testing.T) {
+	root := t.TempDir()
+	state := filepath.Join(t.TempDir(), IndexFile)
+	dir := filepath.Join(root, "a")
+	writeItem(t, dir, "one", "id1")
+	writeItem(t, dir, "two", "id2")
+	writeItem(t, filepath.Join(root, "gone"), "old", "id3")
+
+	ix := NewIndex(root)
+	if n, err := ix.Warm(); err != nil || n != 3 {
+		t.Fatalf("warm: %d dirs, %v", n, err)
+	}
+	if err := ix.Save(state); err != nil {
+		t.Fatal(err)
+	}
+
+	// An edit keeping size and mtime is invisible to the saved index,
+	// which shows the item was not re-parsed after loading.
+	nfoOne := filepath.Join(dir, "one.nfo")
+	fi, err := os.Stat(nfoOne)
+	if err != nil {
+		t.Fatal(err)
+	}
+	write(t, nfoOne, "<movie><title>ONE</title></movie>")
+	if err := os.Chtimes(nfoOne, fi.ModTime(), fi.ModTime()); err != nil {
+		t.Fatal(err)
+	}
+	write(t, filepath.Join(dir, "two.nfo"), "<movie><title>changed</title></movie>")
+	if err := os.RemoveAll(filepath.Join(root, "gone")); err != nil {
+		t.Fatal(err)
+	}
+
+	ix = NewIndex(root)
+	if err := ix.Load(state); err != nil {
+		t.Fatal(err)
+	}
+	if ix.items.Len() != 3 {
+		t.Fatalf("expected 3 loaded items, got %d", ix.items.Len())
+	}
+	if _, err := ix.Warm(); err != nil {
+		t.Fatal(err)
+	}
+	if ix.items.Len() != 2 {
+		t.Fatalf("expected items of removed folder to be dropped, got %d", ix.items.Len())
+	}
+	l, err := ix.Listing("a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	titles := map[string]bool{}
+	for _, v := range l.Videos {
+		titles[v.Title] = true
+	}
+	if !titles["one"] || !titles["changed"] {
+		t.Fatalf("expected cached %q and re-parsed %q, got %v", "one", "changed", titles)
+	}
+}
+
+func TestIndex_LoadIgnoresOtherRoot(t *testing.T) {
+	root := t.TempDir()
+	state := filepath.Join(t.TempDir(), IndexFile)
+	writeItem(t, root, "one", "id1")
+	ix := NewIndex(root)
+	if _, err := ix.Warm(); err != nil {
+		t.Fatal(err)
+	}
+	if err := ix.Save(state); err != nil {
+		t.Fatal(err)
+	}
+	other := NewIndex(t.TempDir())
+	if err := other.Load(state); err != nil {
+		t.Fatal(err)
+	}
+	if other.items.Len() != 0 {
+		t.Fatalf("expected index of another root to be ignored, got %d items", other.items.Len())
+	}
+	if err := NewIndex(root).Load(filepath.Join(t.TempDir(), "missing.json")); err != nil {
+		t.Fatalf("missing index should not be an error: %v", err)
+	}
+}
+
+func TestIndex_WarmSkipsHiddenAndIgnored(t *testing.T) {
+	root := t.TempDir()
+	writeItem(t, filepath.Join(root, "a"), "one", "id1")
+	writeItem(t, filepath.Join(root, "scratch", "sub"), "two", "id2")
+	writeItem(t, filepath.Join(root, "private"), "three", "id3")
+	write(t, filepath.Join(root, browse.IgnoreFileName), "scratch/\n")
+	write(t, filepath.Join(root, "private", browse.DirConfigName), `{"hidden": true}`)
+
+	ix := NewIndex(root)
+	if n, err := ix.Warm(); err != nil || n != 2 {
+		t.Fatalf("warm: %d dirs, %v", n, err)
+	}
+	if ix.items.Len() != 1 {
+		t.Fatalf("expected only the visible item to be parsed, got %d", ix.items.Len())
+	}
+}
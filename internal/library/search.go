@@ -0,0 +1,222 @@
+package library
+
+import (
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/claes/ytplv/internal/model"
+)
+
+// Field weights for ranking: a hit in the title counts most, then tags and
+// the file name, then the plot.
+const (
+	weightTitle = 4
+	weightTag   = 3
+	weightName  = 2
+	weightPlot  = 1
+)
+
+// searchIndex is an inverted index over the videos of the whole root, by
+// search term and by tag.
+type searchIndex struct {
+	docs     []model.Entry        // Path is the folder of the item
+	postings map[string][]posting // token -> docs containing it
+	tokens   []string             // sorted keys of postings, for prefix lookups
+	tags     map[string]*tagDocs  // lower-case tag -> docs tagged with it
+}
+
+type tagDocs struct {
+	name string // spelling of the first occurrence
+	docs []int
+}
+
+type posting struct {
+	doc    int
+	weight int // highest field weight the token occurs in
+}
+
+// Search returns the videos under the root matching every term of q, best
+// matches first. A term matches words it is a prefix of, so partially typed
+// words find results; whole-word matches rank higher. Entry.Path is set to
+// the folder holding each item.
+//
+// The index is built on first use and reused until the library changes,
+// which is only noticed while the tree is watched; otherwise every search
+// rebuilds it from Videos.
+func (ix *Index) Search(q string) []model.Entry {
+	terms := Terms(q)
+	if len(terms) == 0 {
+		return nil
+	}
+	return ix.searchIndex().search(terms)
+}
+
+func (ix *Index) searchIndex() *searchIndex {
+	ix.mu.RLock()
+	si, gen, watching := ix.search, ix.gen, ix.watcher != nil
+	fresh := si != nil && watching && ix.searchGen == gen
+	ix.mu.RUnlock()
+	if fresh {
+		return si
+	}
+	si = buildSearchIndex(ix.Videos())
+	ix.mu.Lock()
+	if ix.gen == gen {
+		ix.search, ix.searchGen = si, gen
+	}
+	ix.mu.Unlock()
+	return si
+}
+
+// unwatchedTTL is how long Videos reuses a walk when the tree is not
+// watched, and changes therefore go unnoticed.
+const unwatchedTTL = 10 * time.Second
+
+// Videos returns the video entries of every folder under the root, folder
+// by folder in walk order, with Entry.Path set to the item's folder.
+//
+// The walk is reused until the library changes while the tree is watched,
+// and for unwatchedTTL otherwise, so that pages showing library-wide counts
+// do not rescan the tree on every load.
+func (ix *Index) Videos() []model.Entry {
+	ix.mu.RLock()
+	out, gen, at, watching := ix.videos, ix.gen, ix.videosAt, ix.watcher != nil
+	fresh := !at.IsZero() && ix.videosGen == gen && (watching || time.Since(at) < unwatchedTTL)
+	ix.mu.RUnlock()
+	if !fresh {
+		out, _ = ix.VideosUnder("")
+		ix.mu.Lock()
+		if ix.gen == gen {
+			ix.videos, ix.videosGen, ix.videosAt = out, gen, time.Now()
+		}
+		ix.mu.Unlock()
+	}
+	return append([]model.Entry(nil), out...)
+}
+
+// VideosUnder is Videos for the subtree at rel. It fails only when rel
+// itself cannot be listed; unreadable folders below it are skipped, and so
+// are hidden and ignored ones, as they are not in their parent's listing.
+func (ix *Index) VideosUnder(rel string) ([]model.Entry, error) {
+	l, err := ix.Listing(rel)
+	if err != nil {
+		return nil, err
+	}
+	var out []model.Entry
+	var walk func(l model.Listing)
+	walk = func(l model.Listing) {
+		for _, e := range l.Entries {
+			if e.Kind == "video" {
+				e.Path = l.Path
+				out = append(out, e)
+			}
+		}
+		for _, d := range l.Dirs {
+			if sub, err := ix.Listing(joinRel(l.Path, d)); err == nil {
+				walk(sub)
+			}
+		}
+	}
+	walk(l)
+	return out, nil
+}
+
+func buildSearchIndex(docs []model.Entry) *searchIndex {
+	si := &searchIndex{docs: docs, postings: make(map[string][]posting), tags: make(map[string]*tagDocs)}
+	for i, e := range docs {
+		best := make(map[string]int)
+		add := func(text string, w int) {
+			for _, t := range Terms(text) {
+				if w > best[t] {
+					best[t] = w
+				}
+			}
+		}
+		v := e.Video
+		add(v.Title, weightTitle)
+		add(v.Name, weightName)
+		add(v.Plot, weightPlot)
+		for _, tag := range v.Tags {
+			add(tag, weightTag)
+			key := strings.ToLower(tag)
+			td := si.tags[key]
+			if td == nil {
+				td = &tagDocs{name: tag}
+				si.tags[key] = td
+			}
+			if n := len(td.docs); n == 0 || td.docs[n-1] != i {
+				td.docs = append(td.docs, i)
+			}
+		}
+		for t, w := range best {
+			si.postings[t] = append(si.postings[t], posting{doc: i, weight: w})
+		}
+	}
+	si.tokens = make([]string, 0, len(si.postings))
+	for t := range si.postings {
+		si.tokens = append(si.tokens, t)
+	}
+	sort.Strings(si.tokens)
+	return si
+}
+
+// search scores the documents containing every term. A whole-word hit
+// scores twice its field weight, a prefix hit the weight alone.
+func (si *searchIndex) search(terms []string) []model.Entry {
+	var scores map[int]int
+	for _, term := range terms {
+		hits := make(map[int]int)
+		i := sort.SearchStrings(si.tokens, term)
+		for ; i < len(si.tokens) && strings.HasPrefix(si.tokens[i], term); i++ {
+			tok := si.tokens[i]
+			for _, p := range si.postings[tok] {
+				s := p.weight
+				if tok == term {
+					s *= 2
+				}
+				hits[p.doc] = max(hits[p.doc], s)
+			}
+		}
+		if scores == nil {
+			scores = hits
+			continue
+		}
+		for doc, s := range scores {
+			if h, ok := hits[doc]; ok {
+				scores[doc] = s + h
+			} else {
+				delete(scores, doc)
+			}
+		}
+	}
+	ids := make([]int, 0, len(scores))
+	for doc := range scores {
+		ids = append(ids, doc)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		a, b := ids[i], ids[j]
+		if scores[a] != scores[b] {
+			return scores[a] > scores[b]
+		}
+		ma, mb := si.docs[a].ModTime, si.docs[b].ModTime
+		if !ma.Equal(mb) {
+			return ma.After(mb)
+		}
+		return a < b
+	})
+	out := make([]model.Entry, len(ids))
+	for i, doc := range ids {
+		out[i] = si.docs[doc]
+	}
+	return out
+}
+
+// Terms splits text into lower-case search terms at anything that is not a
+// letter or digit.
+func Terms(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
@@ -0,0 +1,98 @@
+package library
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func writeNFO(t *testing.T, dir, name, nfo string) {
+	t.Helper()
+	write(t, filepath.Join(dir, name+".strm"), "plugin://plugin.video.youtube/play/?video_id="+name)
+	write(t, filepath.Join(dir, name+".nfo"), nfo)
+}
+
+func searchTitles(ix *Index, q string) []string {
+	var out []string
+	for _, e := range ix.Search(q) {
+		out = append(out, e.Path+"|"+e.Video.Title)
+	}
+	return out
+}
+
+func TestSearch_RanksAcrossFolders(t *testing.T) {
+	root := t.TempDir()
+	writeNFO(t, filepath.Join(root, "a"), "filters", "<movie><title>Strange Filters</title><plot>Synths and tape</plot></movie>")
+	writeNFO(t, filepath.Join(root, "b", "c"), "tape", "<movie><title>Tape Loops</title><tag>synth</tag></movie>")
+	writeNFO(t, root, "other", "<movie><title>Unrelated</title><plot>Nothing here</plot></movie>")
+	ix := NewIndex(root)
+
+	// whole-word title hit ranks above a plot hit
+	if got, want := searchTitles(ix, "tape"), []string{"b/c|Tape Loops", "a|Strange Filters"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("tape: got %v, want %v", got, want)
+	}
+	// prefix terms, all of which must match; tags count
+	if got, want := searchTitles(ix, "SYNTH loo"), []string{"b/c|Tape Loops"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("synth loo: got %v, want %v", got, want)
+	}
+	// file names are searchable
+	if got, want := searchTitles(ix, "other"), []string{"|Unrelated"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("other: got %v, want %v", got, want)
+	}
+	if got := ix.Search("  "); got != nil {
+		t.Fatalf("empty query should return nothing, got %v", got)
+	}
+}
+
+func TestTerms(t *testing.T) {
+	got := Terms("Åsa-Nisse, del 2: Nöjesfältet!")
+	want := []string{"åsa", "nisse", "del", "2", "nöjesfältet"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func TestTags_CountsAndListings(t *testing.T) {
+	root := t.TempDir()
+	writeNFO(t, filepath.Join(root, "a"), "one", "<movie><title>One</title><tag>Synth</tag><tag>synth</tag><tag>Tape</tag></movie>")
+	writeNFO(t, filepath.Join(root, "b"), "two", "<movie><title>Two</title><tag>synth</tag></movie>")
+	ix := NewIndex(root)
+
+	want := []TagCount{{Tag: "Synth", Count: 2}, {Tag: "Tape", Count: 1}}
+	if got := ix.Tags(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("tags: got %v, want %v", got, want)
+	}
+	var got []string
+	for _, e := range ix.Tagged("SYNTH") {
+		got = append(got, e.Path+"|"+e.Video.Title)
+	}
+	if len(got) != 2 || got[0] == got[1] {
+		t.Fatalf("tagged synth: got %v", got)
+	}
+	if ix.Tagged("nope") != nil {
+		t.Fatalf("expected nil for unknown tag")
+	}
+}
+
+func TestVideosUnder(t *testing.T) {
+	root := t.TempDir()
+	writeNFO(t, filepath.Join(root, "a"), "one", "<movie><title>One</title></movie>")
+	writeNFO(t, filepath.Join(root, "a", "b"), "two", "<movie><title>Two</title></movie>")
+	writeNFO(t, filepath.Join(root, "c"), "three", "<movie><title>Three</title></movie>")
+	ix := NewIndex(root)
+
+	es, err := ix.VideosUnder("a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, e := range es {
+		got = append(got, e.Path+"|"+e.Video.Title)
+	}
+	if want := []string{"a|One", "a/b|Two"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	if _, err := ix.VideosUnder("missing"); err == nil {
+		t.Fatalf("expected error for missing folder")
+	}
+}
@@ -0,0 +1,54 @@
+package library
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/claes/ytplv/internal/model"
+)
+
+// TagCount is a tag with the number of videos carrying it.
+type TagCount struct {
+	Tag   string
+	Count int
+}
+
+// Tags returns every tag used under the root with its video count, sorted
+// by name. Tags differing only in case are counted as one.
+func (ix *Index) Tags() []TagCount {
+	si := ix.searchIndex()
+	out := make([]TagCount, 0, len(si.tags))
+	for _, td := range si.tags {
+		out = append(out, TagCount{Tag: td.name, Count: len(td.docs)})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		a, b := strings.ToLower(out[i].Tag), strings.ToLower(out[j].Tag)
+		if a == b {
+			return out[i].Tag < out[j].Tag
+		}
+		return a < b
+	})
+	return out
+}
+
+// Tagged returns the videos tagged with tag (in any case), newest first,
+// with Entry.Path set to the folder holding each item.
+func (ix *Index) Tagged(tag string) []model.Entry {
+	si := ix.searchIndex()
+	td := si.tags[strings.ToLower(tag)]
+	if td == nil {
+		return nil
+	}
+	out := make([]model.Entry, len(td.docs))
+	for i, doc := range td.docs {
+		out[i] = si.docs[doc]
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		mi, mj := out[i].ModTime, out[j].ModTime
+		if mi.Equal(mj) {
+			return strings.ToLower(out[i].Name) < strings.ToLower(out[j].Name)
+		}
+		return mi.After(mj)
+	})
+	return out
+}
@@ -0,0 +1,150 @@
+//go:build linux
+
+package library
+
+import (
+	"errors"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"unsafe"
+)
+
+const watchMask = syscall.IN_CREATE | syscall.IN_DELETE | syscall.IN_CLOSE_WRITE |
+	syscall.IN_MODIFY | syscall.IN_ATTRIB | syscall.IN_MOVED_FROM | syscall.IN_MOVED_TO |
+	syscall.IN_DELETE_SELF | syscall.IN_MOVE_SELF | syscall.IN_ONLYDIR
+
+// inotifyWatcher watches every directory of the tree with one inotify instance.
+type inotifyWatcher struct {
+	fd       int
+	file     *os.File // fd wrapped for the runtime poller, so Close unblocks Read
+	onChange func(dir, name string, isDir bool)
+
+	mu   sync.Mutex
+	dirs map[int32]string // watch descriptor -> directory
+	done chan struct{}
+}
+
+func startWatcher(root string, onChange func(dir, name string, isDir bool)) (watcher, error) {
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return nil, os.NewSyscallError("inotify_init1", err)
+	}
+	w := &inotifyWatcher{
+		fd:       fd,
+		file:     os.NewFile(uintptr(fd), "inotify"),
+		onChange: onChange,
+		dirs:     make(map[int32]string),
+		done:     make(chan struct{}),
+	}
+	if err := w.addTree(root); err != nil {
+		w.file.Close()
+		return nil, err
+	}
+	go w.loop()
+	return w, nil
+}
+
+// addTree watches dir and every directory below it.
+func (w *inotifyWatcher) addTree(dir string) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == dir {
+				return err
+			}
+			return nil
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		wd, err := syscall.InotifyAddWatch(w.fd, path, watchMask)
+		if err != nil {
+			if errors.Is(err, syscall.ENOSPC) {
+				// out of watches: report it, the caller falls back to rescanning
+				return os.NewSyscallError("inotify_add_watch", err)
+			}
+			slog.Warn("library watch failed", "dir", path, "err", err)
+			return nil
+		}
+		w.mu.Lock()
+		w.dirs[int32(wd)] = path
+		w.mu.Unlock()
+		return nil
+	})
+}
+
+func (w *inotifyWatcher) loop() {
+	defer close(w.done)
+	buf := make([]byte, 64*1024)
+	for {
+		n, err := w.file.Read(buf)
+		if err != nil {
+			return // closed
+		}
+		if n <= 0 {
+			return
+		}
+		for off := 0; off+syscall.SizeofInotifyEvent <= n; {
+			ev := (*syscall.InotifyEvent)(unsafe.Pointer(&buf[off]))
+			nameBytes := buf[off+syscall.SizeofInotifyEvent : off+syscall.SizeofInotifyEvent+int(ev.Len)]
+			off += syscall.SizeofInotifyEvent + int(ev.Len)
+			w.handle(ev, cstring(nameBytes))
+		}
+	}
+}
+
+func (w *inotifyWatcher) handle(ev *syscall.InotifyEvent, name string) {
+	if ev.Mask&syscall.IN_Q_OVERFLOW != 0 {
+		// events were lost; treat everything as changed
+		w.mu.Lock()
+		dirs := make([]string, 0, len(w.dirs))
+		for _, d := range w.dirs {
+			dirs = append(dirs, d)
+		}
+		w.mu.Unlock()
+		for _, d := range dirs {
+			w.onChange(d, "", false)
+		}
+		return
+	}
+	w.mu.Lock()
+	dir, ok := w.dirs[ev.Wd]
+	if ev.Mask&syscall.IN_IGNORED != 0 {
+		delete(w.dirs, ev.Wd)
+	}
+	w.mu.Unlock()
+	if !ok {
+		return
+	}
+	isDir := ev.Mask&syscall.IN_ISDIR != 0
+	if isDir && ev.Mask&(syscall.IN_CREATE|syscall.IN_MOVED_TO) != 0 {
+		if err := w.addTree(filepath.Join(dir, name)); err != nil {
+			slog.Warn("library watch failed", "dir", filepath.Join(dir, name), "err", err)
+		}
+	}
+	if ev.Mask&(syscall.IN_DELETE_SELF|syscall.IN_MOVE_SELF) != 0 {
+		// reported on the parent by IN_DELETE/IN_MOVED_FROM as well
+		w.onChange(filepath.Dir(dir), filepath.Base(dir), true)
+		return
+	}
+	w.onChange(dir, name, isDir)
+}
+
+// Close stops the watcher and waits for its goroutine to exit.
+func (w *inotifyWatcher) Close() error {
+	err := w.file.Close()
+	<-w.done
+	return err
+}
+
+func cstring(b []byte) string {
+	for i, c := range b {
+		if c == 0 {
+			return string(b[:i])
+		}
+	}
+	return string(b)
+}
@@ -0,0 +1,7 @@
+//go:build !linux
+
+package library
+
+func startWatcher(root string, onChange func(dir, name string, isDir bool)) (watcher, error) {
+	return nil, errNoWatcher
+}
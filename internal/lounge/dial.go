@@ -0,0 +1,145 @@
+package lounge
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	nethttp "net/http"
+	"strings"
+	"time"
+)
+
+// SSDP search for DIAL devices.
+const (
+	ssdpAddr = "239.255.255.250:1900"
+	dialST   = "urn:dial-multiscreen-org:service:dial:1"
+)
+
+// Discover searches the local network for DIAL devices with SSDP and returns
+// the device description URLs that answered within wait.
+func Discover(ctx context.Context, wait time.Duration) ([]string, error) {
+	conn, err := net.ListenPacket("udp4", ":0")
+	if err != nil {
+		return nil, &Error{Op: "dial", Err: err}
+	}
+	defer conn.Close()
+	dst, err := net.ResolveUDPAddr("udp4", ssdpAddr)
+	if err != nil {
+		return nil, &Error{Op: "dial", Err: err}
+	}
+	msg := "M-SEARCH * HTTP/1.1\r\n" +
+		"HOST: " + ssdpAddr + "\r\n" +
+		"MAN: \"ssdp:discover\"\r\n" +
+		"MX: 1\r\n" +
+		"ST: " + dialST + "\r\n\r\n"
+	if _, err := conn.WriteTo([]byte(msg), dst); err != nil {
+		return nil, &Error{Op: "dial", Err: err}
+	}
+	deadline := time.Now().Add(wait)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	_ = conn.SetReadDeadline(deadline)
+	var locations []string
+	seen := make(map[string]bool)
+	buf := make([]byte, 4096)
+	for {
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			var ne net.Error
+			if errors.As(err, &ne) && ne.Timeout() {
+				break
+			}
+			return locations, &Error{Op: "dial", Err: err}
+		}
+		if loc, ok := parseSSDPResponse(buf[:n]); ok && !seen[loc] {
+			seen[loc] = true
+			locations = append(locations, loc)
+		}
+	}
+	slog.Debug("dial discovery", "devices", len(locations))
+	return locations, nil
+}
+
+// parseSSDPResponse returns the LOCATION of an SSDP answer for DIAL.
+func parseSSDPResponse(b []byte) (string, bool) {
+	resp, err := nethttp.ReadResponse(bufio.NewReader(bytes.NewReader(b)), nil)
+	if err != nil {
+		return "", false
+	}
+	resp.Body.Close()
+	if resp.StatusCode != nethttp.StatusOK || !strings.EqualFold(resp.Header.Get("ST"), dialST) {
+		return "", false
+	}
+	loc := resp.Header.Get("Location")
+	return loc, loc != ""
+}
+
+// DIALScreen reads the DIAL device description at location, as found by
+// Discover, and the state of its YouTube app. It returns the screen of a TV
+// whose YouTube app is running, without a lounge token.
+func (c *Client) DIALScreen(ctx context.Context, location string) (Screen, error) {
+	var desc struct {
+		FriendlyName string `xml:"device>friendlyName"`
+	}
+	resp, err := c.get(ctx, location)
+	if err != nil {
+		return Screen{}, err
+	}
+	appURL := resp.Header.Get("Application-URL")
+	err = decodeXML(resp, &desc)
+	if err != nil {
+		return Screen{}, err
+	}
+	if appURL == "" {
+		return Screen{}, &Error{Op: "dial", Err: fmt.Errorf("%s: no Application-URL", location)}
+	}
+	var app struct {
+		State    string `xml:"state"`
+		ScreenID string `xml:"additionalData>screenId"`
+	}
+	resp, err = c.get(ctx, strings.TrimSuffix(appURL, "/")+"/YouTube")
+	if err != nil {
+		return Screen{}, err
+	}
+	if err := decodeXML(resp, &app); err != nil {
+		return Screen{}, err
+	}
+	if app.ScreenID == "" {
+		return Screen{}, &Error{Op: "dial", Err: fmt.Errorf("%s: YouTube app is %s; open it to pair", desc.FriendlyName, app.State)}
+	}
+	return Screen{ID: app.ScreenID, Name: desc.FriendlyName}, nil
+}
+
+// get issues a DIAL GET request and returns the 200 answer.
+func (c *Client) get(ctx context.Context, rawURL string) (*nethttp.Response, error) {
+	req, err := nethttp.NewRequestWithContext(ctx, nethttp.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, &Error{Op: "dial", Err: err}
+	}
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return nil, &Error{Op: "dial", Err: err}
+	}
+	if resp.StatusCode != nethttp.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		resp.Body.Close()
+		return nil, &Error{Op: "dial", Status: resp.StatusCode, Body: snippet(body), Err: fmt.Errorf("%s: %s", rawURL, nethttp.StatusText(resp.StatusCode))}
+	}
+	return resp, nil
+}
+
+// decodeXML decodes and closes the body of resp.
+func decodeXML(resp *nethttp.Response, v any) error {
+	defer resp.Body.Close()
+	if err := xml.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(v); err != nil {
+		return &Error{Op: "dial", Status: resp.StatusCode, Err: fmt.Errorf("decode %s: %w", resp.Request.URL, err)}
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package lounge
+
+import (
+	"context"
+	nethttp "net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestParseSSDPResponse(t *testing.T) {
+	ok := "HTTP/1.1 200 OK\r\n" +
+		"CACHE-CONTROL: max-age=1800\r\n" +
+		"LOCATION: http://192.168.1.20:8008/ssdp/device-desc.xml\r\n" +
+		"ST: urn:dial-multiscreen-org:service:dial:1\r\n" +
+		"USN: uuid:abc::urn:dial-multiscreen-org:service:dial:1\r\n\r\n"
+	if loc, found := parseSSDPResponse([]byte(ok)); !found || loc != "http://192.168.1.20:8008/ssdp/device-desc.xml" {
+		t.Fatalf("parseSSDPResponse = %q, %v", loc, found)
+	}
+	other := strings.Replace(ok, "urn:dial-multiscreen-org:service:dial:1\r\nUSN", "upnp:rootdevice\r\nUSN", 1)
+	if _, found := parseSSDPResponse([]byte(other)); found {
+		t.Fatal("accepted an answer for another search target")
+	}
+	if _, found := parseSSDPResponse([]byte("garbage")); found {
+		t.Fatal("accepted garbage")
+	}
+}
+
+func TestDIALScreen(t *testing.T) {
+	appState := "running"
+	var srv *httptest.Server
+	srv = httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		switch r.URL.Path {
+		case "/dd.xml":
+			w.Header().Set("Application-URL", srv.URL+"/apps/")
+			w.Write([]byte(`<?xml version="1.0"?>
+<root xmlns="urn:schemas-upnp-org:device-1-0">
+  <device><friendlyName>Bedroom TV</friendlyName></device>
+</root>`))
+		case "/apps/YouTube":
+			screen := "<screenId>scr9</screenId>"
+			if appState != "running" {
+				screen = ""
+			}
+			w.Write([]byte(`<service xmlns="urn:dial-multiscreen-org:schemas:dial" dialVer="2.1">
+  <name>YouTube</name><state>` + appState + `</state>
+  <additionalData>` + screen + `<theme>cl</theme></additionalData>
+</service>`))
+		default:
+			nethttp.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	c := NewClient("castweb")
+
+	s, err := c.DIALScreen(context.Background(), srv.URL+"/dd.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.ID != "scr9" || s.Name != "Bedroom TV" || s.Token != "" {
+		t.Fatalf("unexpected screen %+v", s)
+	}
+
+	appState = "stopped"
+	if _, err := c.DIALScreen(context.Background(), srv.URL+"/dd.xml"); err == nil || !strings.Contains(err.Error(), "stopped") {
+		t.Fatalf("expected error for stopped app, got %v", err)
+	}
+	if _, err := c.DIALScreen(context.Background(), srv.URL+"/missing.xml"); err == nil {
+		t.Fatal("expected error for missing description")
+	}
+}
@@ -0,0 +1,324 @@
+// Package lounge is a client of the YouTube Lounge API, which remote controls
+// use to drive the YouTube app on TVs, and of the DIAL protocol that finds
+// such TVs on the local network.
+//
+// A screen is paired once, with the code shown under "Link with TV code" or
+// through DIAL, giving its screen ID. The ID is exchanged for a lounge token,
+// which authorises a Session sending commands such as setPlaylist.
+package lounge
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	nethttp "net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultBaseURL is the lounge API of YouTube.
+const DefaultBaseURL = "https://www.youtube.com/api/lounge"
+
+// Errors of the lounge API, wrapped in an *Error.
+var (
+	ErrInvalidCode  = errors.New("pairing code not found")
+	ErrUnauthorized = errors.New("lounge token rejected")
+	ErrSessionGone  = errors.New("session expired")
+)
+
+// Error is a failed lounge or DIAL request.
+type Error struct {
+	Op     string // "pair", "token", "connect", a command such as "setPlaylist", or "dial"
+	Status int    // HTTP status; 0 when no response was received
+	Body   string // start of the response body, for logs
+	Err    error
+}
+
+func (e *Error) Error() string {
+	if e.Status == 0 {
+		return fmt.Sprintf("lounge %s: %v", e.Op, e.Err)
+	}
+	if e.Body == "" {
+		return fmt.Sprintf("lounge %s: status %d: %v", e.Op, e.Status, e.Err)
+	}
+	return fmt.Sprintf("lounge %s: status %d: %v: %s", e.Op, e.Status, e.Err, e.Body)
+}
+
+func (e *Error) Unwrap() error { return e.Err }
+
+// Screen is a paired TV.
+type Screen struct {
+	ID      string    `json:"id"`
+	Name    string    `json:"name,omitempty"`
+	Token   string    `json:"lounge_token,omitempty"`
+	Expires time.Time `json:"expires,omitzero"` // of Token
+}
+
+// Fresh reports whether the lounge token is set and valid for another
+// minute.
+func (s Screen) Fresh(now time.Time) bool {
+	return s.Token != "" && (s.Expires.IsZero() || now.Add(time.Minute).Before(s.Expires))
+}
+
+// Client talks to the lounge API as one remote control.
+type Client struct {
+	BaseURL string // default DefaultBaseURL
+	HTTP    *nethttp.Client
+	Name    string // remote name shown on the TV
+	ID      string // remote ID; a random one per Client
+}
+
+// NewClient returns a client calling itself name on the TVs it connects to.
+func NewClient(name string) *Client {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	return &Client{BaseURL: DefaultBaseURL, HTTP: &nethttp.Client{Timeout: 15 * time.Second}, Name: name, ID: hex.EncodeToString(b[:])}
+}
+
+type screenJSON struct {
+	ID         string `json:"screenId"`
+	Name       string `json:"name"`
+	Token      string `json:"loungeToken"`
+	Expiration int64  `json:"expiration"` // Unix milliseconds
+}
+
+func (j screenJSON) screen() Screen {
+	s := Screen{ID: j.ID, Name: j.Name, Token: j.Token}
+	if j.Expiration > 0 {
+		s.Expires = time.UnixMilli(j.Expiration)
+	}
+	return s
+}
+
+// Pair returns the screen showing the TV code.
+func (c *Client) Pair(ctx context.Context, code string) (Screen, error) {
+	var r struct {
+		Screen screenJSON `json:"screen"`
+	}
+	if err := c.postJSON(ctx, "pair", "/pairing/get_screen", url.Values{"pairing_code": {code}}, &r); err != nil {
+		return Screen{}, err
+	}
+	if r.Screen.ID == "" {
+		return Screen{}, &Error{Op: "pair", Status: nethttp.StatusOK, Err: ErrInvalidCode}
+	}
+	return r.Screen.screen(), nil
+}
+
+// Refresh returns s with a new lounge token, keeping its name when the API
+// does not report one.
+func (c *Client) Refresh(ctx context.Context, s Screen) (Screen, error) {
+	var r struct {
+		Screens []screenJSON `json:"screens"`
+	}
+	if err := c.postJSON(ctx, "token", "/pairing/get_lounge_token_batch", url.Values{"screen_ids": {s.ID}}, &r); err != nil {
+		return s, err
+	}
+	for _, j := range r.Screens {
+		if j.ID != s.ID || j.Token == "" {
+			continue
+		}
+		fresh := j.screen()
+		if fresh.Name == "" {
+			fresh.Name = s.Name
+		}
+		return fresh, nil
+	}
+	return s, &Error{Op: "token", Status: nethttp.StatusOK, Err: fmt.Errorf("no token for screen %s", s.ID)}
+}
+
+// postJSON posts form to path and decodes the JSON answer into v.
+func (c *Client) postJSON(ctx context.Context, op, path string, form url.Values, v any) error {
+	body, err := c.post(ctx, op, c.BaseURL+path, form)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return &Error{Op: op, Status: nethttp.StatusOK, Err: fmt.Errorf("decode response: %w", err)}
+	}
+	return nil
+}
+
+// post posts form to rawURL and returns the body of a 200 answer. Other
+// answers become an *Error wrapping the matching Err value.
+func (c *Client) post(ctx context.Context, op, rawURL string, form url.Values) ([]byte, error) {
+	req, err := nethttp.NewRequestWithContext(ctx, nethttp.MethodPost, rawURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, &Error{Op: op, Err: err}
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	slog.Debug("lounge request", "op", op, "url", rawURL)
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return nil, &Error{Op: op, Err: err}
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, &Error{Op: op, Status: resp.StatusCode, Err: err}
+	}
+	if resp.StatusCode == nethttp.StatusOK {
+		return body, nil
+	}
+	e := &Error{Op: op, Status: resp.StatusCode, Body: snippet(body)}
+	switch {
+	case op == "pair" && resp.StatusCode == nethttp.StatusNotFound:
+		e.Err = ErrInvalidCode
+	case resp.StatusCode == nethttp.StatusUnauthorized:
+		e.Err = ErrUnauthorized
+	case resp.StatusCode == nethttp.StatusGone, resp.StatusCode == nethttp.StatusNotFound,
+		resp.StatusCode == nethttp.StatusBadRequest && strings.Contains(string(body), "Unknown SID"):
+		e.Err = ErrSessionGone
+	default:
+		e.Err = errors.New(nethttp.StatusText(resp.StatusCode))
+	}
+	return nil, e
+}
+
+// snippet returns the start of body on one line.
+func snippet(body []byte) string {
+	s := strings.Join(strings.Fields(string(body)), " ")
+	if len(s) > 200 {
+		s = s[:200] + "..."
+	}
+	return s
+}
+
+// Session is a connection to one screen. Its methods may be called
+// concurrently; commands are sent one at a time.
+type Session struct {
+	c        *Client
+	screen   Screen
+	sid      string
+	gsession string
+	mu       sync.Mutex
+	rid      int // request counter
+	ofs      int // commands sent so far
+}
+
+// Connect opens a session with s, which must have a lounge token.
+func (c *Client) Connect(ctx context.Context, s Screen) (*Session, error) {
+	sess := &Session{c: c, screen: s, rid: 1}
+	body, err := c.post(ctx, "connect", sess.bindURL(false), url.Values{"count": {"0"}})
+	if err != nil {
+		return nil, err
+	}
+	sess.sid, sess.gsession, err = parseBind(body)
+	if err != nil {
+		return nil, &Error{Op: "connect", Status: nethttp.StatusOK, Body: snippet(body), Err: err}
+	}
+	slog.Debug("lounge connected", "screen", s.ID, "sid", sess.sid)
+	return sess, nil
+}
+
+// Screen returns the screen of the session.
+func (sess *Session) Screen() Screen { return sess.screen }
+
+// SetPlaylist plays videoID from start seconds, replacing what plays.
+func (sess *Session) SetPlaylist(ctx context.Context, videoID string, start int) error {
+	return sess.send(ctx, "setPlaylist", url.Values{
+		"videoId":      {videoID},
+		"currentTime":  {strconv.Itoa(start)},
+		"currentIndex": {"-1"},
+		"audioOnly":    {"false"},
+		"listId":       {""},
+	})
+}
+
+// AddVideo appends videoID to the queue.
+func (sess *Session) AddVideo(ctx context.Context, videoID string) error {
+	return sess.send(ctx, "addVideo", url.Values{"videoId": {videoID}})
+}
+
+// StopVideo stops playback.
+func (sess *Session) StopVideo(ctx context.Context) error {
+	return sess.send(ctx, "stopVideo", nil)
+}
+
+// send posts one command with its parameters.
+func (sess *Session) send(ctx context.Context, command string, params url.Values) error {
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+	sess.rid++
+	form := url.Values{
+		"count":    {"1"},
+		"ofs":      {strconv.Itoa(sess.ofs)},
+		"req0__sc": {command},
+	}
+	for k, v := range params {
+		form["req0_"+k] = v
+	}
+	if _, err := sess.c.post(ctx, command, sess.bindURL(true), form); err != nil {
+		return err
+	}
+	sess.ofs++
+	return nil
+}
+
+// bindURL returns the URL of the bind endpoint, identifying the session when
+// withSession is set.
+func (sess *Session) bindURL(withSession bool) string {
+	q := url.Values{
+		"device":        {"REMOTE_CONTROL"},
+		"id":            {sess.c.ID},
+		"name":          {sess.c.Name},
+		"app":           {"castweb"},
+		"loungeIdToken": {sess.screen.Token},
+		"VER":           {"8"},
+		"CVER":          {"1"},
+		"RID":           {strconv.Itoa(sess.rid)},
+	}
+	if withSession {
+		q.Set("SID", sess.sid)
+		q.Set("gsessionid", sess.gsession)
+	}
+	return sess.c.BaseURL + "/bc/bind?" + q.Encode()
+}
+
+// parseBind returns the session ID ("c" event) and gsessionid ("S" event)
+// of a bind answer. The answer is a sequence of chunks, each a length line
+// followed by a JSON array of [index, [event, args...]] entries.
+func parseBind(body []byte) (sid, gsession string, err error) {
+	d := json.NewDecoder(bytes.NewReader(body))
+	for {
+		var chunk json.RawMessage
+		if err := d.Decode(&chunk); err == io.EOF {
+			break
+		} else if err != nil {
+			return "", "", fmt.Errorf("decode bind: %w", err)
+		}
+		var events [][]json.RawMessage
+		if json.Unmarshal(chunk, &events) != nil {
+			continue // a chunk length
+		}
+		for _, ev := range events {
+			if len(ev) < 2 {
+				continue
+			}
+			var args []any
+			if json.Unmarshal(ev[1], &args) != nil || len(args) < 2 {
+				continue
+			}
+			name, _ := args[0].(string)
+			val, _ := args[1].(string)
+			switch name {
+			case "c":
+				sid = val
+			case "S":
+				gsession = val
+			}
+		}
+	}
+	if sid == "" {
+		return "", "", errors.New("no session id in bind answer")
+	}
+	return sid, gsession, nil
+}
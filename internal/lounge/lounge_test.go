@@ -0,0 +1,120 @@
+package lounge
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/claes/ytplv/internal/lounge/loungetest"
+)
+
+func newTestClient(fake *loungetest.Server) *Client {
+	c := NewClient("castweb")
+	c.BaseURL = fake.URL
+	return c
+}
+
+func TestPairConnectAndSend(t *testing.T) {
+	fake := loungetest.NewServer()
+	defer fake.Close()
+	fake.AddScreen("123456789012", "scr1", "Living room TV")
+	c := newTestClient(fake)
+	ctx := context.Background()
+
+	if _, err := c.Pair(ctx, "000000000000"); !errors.Is(err, ErrInvalidCode) {
+		t.Fatalf("pair with unknown code: got %v, want ErrInvalidCode", err)
+	}
+	s, err := c.Pair(ctx, "123456789012")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.ID != "scr1" || s.Name != "Living room TV" || s.Token == "" || !s.Fresh(time.Now()) {
+		t.Fatalf("unexpected screen %+v", s)
+	}
+	sess, err := c.Connect(ctx, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sess.SetPlaylist(ctx, "abc", 90); err != nil {
+		t.Fatal(err)
+	}
+	if err := sess.AddVideo(ctx, "def"); err != nil {
+		t.Fatal(err)
+	}
+	if err := sess.StopVideo(ctx); err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, cmd := range fake.Commands() {
+		got = append(got, cmd.String())
+	}
+	want := []string{
+		"setPlaylist scr1 audioOnly=false&currentIndex=-1&currentTime=90&listId=&videoId=abc",
+		"addVideo scr1 videoId=def",
+		"stopVideo scr1 ",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("commands = %q, want %q", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("command %d = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
+
+func TestRefreshAndExpiry(t *testing.T) {
+	fake := loungetest.NewServer()
+	defer fake.Close()
+	fake.AddScreen("111111111111", "scr1", "TV")
+	c := newTestClient(fake)
+	ctx := context.Background()
+	s, err := c.Pair(ctx, "111111111111")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sess, err := c.Connect(ctx, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fake.ExpireSessions()
+	if err := sess.AddVideo(ctx, "abc"); !errors.Is(err, ErrSessionGone) {
+		t.Fatalf("command on ended session: got %v, want ErrSessionGone", err)
+	}
+	fake.ExpireTokens()
+	if _, err := c.Connect(ctx, s); !errors.Is(err, ErrUnauthorized) {
+		t.Fatalf("connect with expired token: got %v, want ErrUnauthorized", err)
+	}
+	var le *Error
+	if _, err := c.Connect(ctx, s); !errors.As(err, &le) || le.Op != "connect" || le.Status != 401 {
+		t.Fatalf("expected *Error for connect with status 401, got %#v", err)
+	}
+
+	fresh, err := c.Refresh(ctx, Screen{ID: "scr1", Name: "Kept"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fresh.Token == "" || fresh.Token == s.Token || fresh.Name != "TV" {
+		t.Fatalf("unexpected refreshed screen %+v", fresh)
+	}
+	if _, err := c.Refresh(ctx, Screen{ID: "gone"}); err == nil {
+		t.Fatal("expected error refreshing an unknown screen")
+	}
+	if _, err := c.Connect(ctx, fresh); err != nil {
+		t.Fatalf("connect with refreshed token: %v", err)
+	}
+}
+
+func TestParseBind(t *testing.T) {
+	body := "60\n[[0,[\"c\",\"SID1\",\"\",8]],[1,[\"S\",\"GS1\"]],[2,[\"noop\"]]]\n" +
+		"30\n[[3,[\"loungeStatus\",{}]]]\n"
+	sid, gs, err := parseBind([]byte(body))
+	if err != nil || sid != "SID1" || gs != "GS1" {
+		t.Fatalf("parseBind = %q, %q, %v", sid, gs, err)
+	}
+	if _, _, err := parseBind([]byte("12\n[[0,[\"noop\"]]]\n")); err == nil {
+		t.Fatal("expected error without a session id")
+	}
+}
@@ -0,0 +1,196 @@
+// Package loungetest provides a fake lounge API for tests.
+package loungetest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Command is a command received by the fake.
+type Command struct {
+	Screen string     // screen ID
+	Name   string     // e.g. "setPlaylist"
+	Params url.Values // the req0_ parameters without their prefix
+}
+
+// String returns the command as "name screen k=v&...".
+func (c Command) String() string {
+	return c.Name + " " + c.Screen + " " + c.Params.Encode()
+}
+
+// Server is a fake lounge API at its URL. Screens are added with AddScreen.
+type Server struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	screens  map[string]string // screen ID -> name
+	codes    map[string]string // pairing code -> screen ID
+	tokens   map[string]string // valid lounge token -> screen ID
+	sessions map[string]string // session ID -> screen ID
+	commands []Command
+	held     map[string]chan struct{} // screen ID -> closed on release
+	n        int
+}
+
+// NewServer starts a fake lounge API. Close it when done.
+func NewServer() *Server {
+	s := &Server{
+		screens:  make(map[string]string),
+		codes:    make(map[string]string),
+		tokens:   make(map[string]string),
+		sessions: make(map[string]string),
+		held:     make(map[string]chan struct{}),
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /pairing/get_screen", s.getScreen)
+	mux.HandleFunc("POST /pairing/get_lounge_token_batch", s.getTokens)
+	mux.HandleFunc("POST /bc/bind", s.bind)
+	s.Server = httptest.NewServer(mux)
+	return s
+}
+
+// AddScreen adds a screen that pairs with code.
+func (s *Server) AddScreen(code, id, name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.screens[id] = name
+	s.codes[code] = id
+}
+
+// Commands returns the commands received so far.
+func (s *Server) Commands() []Command {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Command(nil), s.commands...)
+}
+
+// ExpireTokens invalidates all lounge tokens handed out so far.
+func (s *Server) ExpireTokens() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	clear(s.tokens)
+}
+
+// ExpireSessions ends all sessions, as when the TV restarts.
+func (s *Server) ExpireSessions() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	clear(s.sessions)
+}
+
+// Hold makes bind requests for screen id wait until release is called, as
+// with a slow TV.
+func (s *Server) Hold(id string) (release func()) {
+	ch := make(chan struct{})
+	s.mu.Lock()
+	s.held[id] = ch
+	s.mu.Unlock()
+	return func() {
+		s.mu.Lock()
+		delete(s.held, id)
+		s.mu.Unlock()
+		close(ch)
+	}
+}
+
+// token returns a new lounge token for screen id; s.mu must be held.
+func (s *Server) token(id string) map[string]any {
+	s.n++
+	tok := fmt.Sprintf("token-%s-%d", id, s.n)
+	s.tokens[tok] = id
+	return map[string]any{
+		"screenId":    id,
+		"name":        s.screens[id],
+		"loungeToken": tok,
+		"expiration":  time.Now().Add(14 * 24 * time.Hour).UnixMilli(),
+	}
+}
+
+func (s *Server) getScreen(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id, ok := s.codes[r.FormValue("pairing_code")]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	writeJSON(w, map[string]any{"screen": s.token(id)})
+}
+
+func (s *Server) getTokens(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	screens := []map[string]any{}
+	for _, id := range strings.Split(r.FormValue("screen_ids"), ",") {
+		if _, ok := s.screens[id]; ok {
+			screens = append(screens, s.token(id))
+		}
+	}
+	writeJSON(w, map[string]any{"screens": screens})
+}
+
+func (s *Server) bind(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	s.mu.Lock()
+	hold := s.held[s.tokens[q.Get("loungeIdToken")]]
+	s.mu.Unlock()
+	if hold != nil {
+		select {
+		case <-hold:
+		case <-r.Context().Done():
+			return
+		}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	screen, ok := s.tokens[q.Get("loungeIdToken")]
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if q.Get("device") != "REMOTE_CONTROL" || q.Get("VER") != "8" || q.Get("RID") == "" {
+		http.Error(w, "bad bind parameters", http.StatusBadRequest)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	sid := q.Get("SID")
+	if sid == "" {
+		s.n++
+		sid = "sid-" + strconv.Itoa(s.n)
+		s.sessions[sid] = screen
+		events := fmt.Sprintf(`[[0,["c",%q,"",8]],[1,["S","gsession-%d"]],[2,["loungeStatus",{}]]]`, sid, s.n)
+		fmt.Fprintf(w, "%d\n%s\n", len(events), events)
+		return
+	}
+	if s.sessions[sid] != screen || q.Get("gsessionid") == "" {
+		http.Error(w, "Unknown SID", http.StatusBadRequest)
+		return
+	}
+	count, _ := strconv.Atoi(r.PostForm.Get("count"))
+	for i := range count {
+		prefix := "req" + strconv.Itoa(i) + "_"
+		c := Command{Screen: screen, Name: r.PostForm.Get(prefix + "_sc"), Params: url.Values{}}
+		for k, v := range r.PostForm {
+			if strings.HasPrefix(k, prefix) && k != prefix+"_sc" {
+				c.Params[strings.TrimPrefix(k, prefix)] = v
+			}
+		}
+		s.commands = append(s.commands, c)
+	}
+	fmt.Fprint(w, "7\n[[3,[]]]\n")
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -4,14 +4,52 @@ import "time"
 
 // Video represents a single playable item with associated metadata.
 type Video struct {
-    Name     string // base filename without extension
-    Type     string // source type: youtube, svtplay
-    VideoID  string
-    URL      string // optional absolute URL (from .url files), takes precedence
-    Title    string
-    Plot     string
-    ThumbURL string
-    Tags     []string
+    Name       string // base filename without extension
+    Type       string // source type: youtube, svtplay
+    Kind       string // nfo root kind: movie, episode, tvshow, musicvideo
+    VideoID    string
+    VideoIDs   []string // youtube-playlist: known video ids, in play order
+    URL        string // optional absolute URL (from .url files), takes precedence
+    STRM       string // the .strm line as written (often a Kodi plugin:// URL), "" for other items
+    Start      int    // start offset in seconds (from t= or start=), 0 if none
+    Title      string
+    SortTitle  string
+    Plot       string
+    ThumbURL   string
+    Tags       []string
+    Premiered  string // premiered or aired date as written in the .nfo
+    Runtime    int    // minutes
+    Genres     []string
+    Studios    []string
+    Directors  []string
+    UniqueIDs  []UniqueID
+    Fanart     []string // fanart image URLs or relative paths
+    Ratings    []Rating
+    PlayCount  int
+    LastPlayed string
+    ShowTitle  string // episode: series title
+    Season     int    // episode: season number
+    Episode    int    // episode: episode number within season
+    Artists    []string // musicvideo
+    Album      string   // musicvideo
+    Resume     int      // resume point in seconds from the .nfo, 0 if none
+    Total      int      // total length in seconds recorded with the resume point
+}
+
+// UniqueID is a provider-scoped identifier such as a YouTube or IMDb id.
+type UniqueID struct {
+    Type    string
+    Value   string
+    Default bool
+}
+
+// Rating is a single rating value from a named source.
+type Rating struct {
+    Name    string
+    Value   float64
+    Max     float64
+    Votes   int
+    Default bool
 }
 
 // Listing represents the contents of a directory.
@@ -28,6 +66,18 @@ type Entry struct {
 	Kind    string    // "dir" or "video"
 	Name    string    // directory name or video base name/title for display
 	Path    string    // for Kind=="dir": relative path to directory
-	ModTime time.Time // source: .strm mod time (or best-effort)
+	ModTime time.Time // item date: NFO premiered/aired, sorttitle timestamp, else file mtime; for dirs the newest item
+	Added   time.Time // for Kind=="video": when the item first appeared in the library
 	Video   *Video    // populated when Kind=="video"
+	Folder  *Folder   // populated when Kind=="dir" and folder metadata exists
+}
+
+// Folder carries metadata for a directory, read from tvshow.nfo/folder.nfo
+// and folder artwork inside it.
+type Folder struct {
+	Title    string
+	Plot     string
+	ThumbURL string // relative to the listing directory, or absolute URL
+	Fanart   string // relative to the listing directory, or absolute URL
+	Icon     string // emoji, or image relative to the listing directory or absolute URL
 }
@@ -0,0 +1,116 @@
+package parser
+
+import (
+	"bytes"
+	"io"
+	"regexp"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+var (
+	bomUTF8    = []byte{0xEF, 0xBB, 0xBF}
+	bomUTF16LE = []byte{0xFF, 0xFE}
+	bomUTF16BE = []byte{0xFE, 0xFF}
+
+	xmlEncodingRe = regexp.MustCompile(`^\s*<\?xml[^>]*encoding\s*=\s*["']([A-Za-z0-9._-]+)["']`)
+)
+
+// toUTF8 converts raw .nfo bytes to UTF-8. It honours a byte-order mark, then
+// the encoding declared in the XML prolog, and finally treats invalid UTF-8 as
+// Windows-1252, which is what mislabelled Latin-1 files usually are.
+func toUTF8(b []byte) []byte {
+	switch {
+	case bytes.HasPrefix(b, bomUTF8):
+		b = b[len(bomUTF8):]
+	case bytes.HasPrefix(b, bomUTF16LE):
+		return utf16ToUTF8(b[2:], false)
+	case bytes.HasPrefix(b, bomUTF16BE):
+		return utf16ToUTF8(b[2:], true)
+	}
+	if m := xmlEncodingRe.FindSubmatch(b); m != nil {
+		if decode := labelDecoder(string(m[1])); decode != nil {
+			return decode(b)
+		}
+	}
+	if !utf8.Valid(b) {
+		return cp1252ToUTF8(b)
+	}
+	return b
+}
+
+// labelDecoder returns the decoder for a single-byte encoding label, or nil
+// when the label is not one. Latin-1 is decoded as Windows-1252, its
+// superset in practice; Latin-9 differs from both in eight places.
+func labelDecoder(label string) func([]byte) []byte {
+	switch strings.ToLower(label) {
+	case "iso-8859-1", "iso8859-1", "iso_8859-1", "latin1", "latin-1", "l1",
+		"windows-1252", "cp1252", "x-cp1252":
+		return cp1252ToUTF8
+	case "iso-8859-15", "iso8859-15", "iso_8859-15", "latin-9", "latin9", "l9":
+		return latin9ToUTF8
+	}
+	return nil
+}
+
+// passthroughCharset lets encoding/xml accept any declared encoding; input
+// has already been converted to UTF-8 by toUTF8.
+func passthroughCharset(_ string, input io.Reader) (io.Reader, error) {
+	return input, nil
+}
+
+// cp1252High maps bytes 0x80-0x9F of Windows-1252; 0xA0-0xFF match Latin-1.
+var cp1252High = [32]rune{
+	'€', 0x81, '‚', 'ƒ', '„', '…', '†', '‡', 'ˆ', '‰', 'Š', '‹', 'Œ', 0x8D, 'Ž', 0x8F,
+	0x90, '‘', '’', '“', '”', '•', '–', '—', '˜', '™', 'š', '›', 'œ', 0x9D, 'ž', 'Ÿ',
+}
+
+func cp1252ToUTF8(b []byte) []byte {
+	out := make([]byte, 0, len(b)+len(b)/4)
+	for _, c := range b {
+		switch {
+		case c < 0x80:
+			out = append(out, c)
+		case c < 0xA0:
+			out = utf8.AppendRune(out, cp1252High[c-0x80])
+		default:
+			out = utf8.AppendRune(out, rune(c))
+		}
+	}
+	return out
+}
+
+// latin9Diff maps the bytes where ISO-8859-15 differs from Latin-1.
+var latin9Diff = map[byte]rune{
+	0xA4: '€', 0xA6: 'Š', 0xA8: 'š', 0xB4: 'Ž', 0xB8: 'ž', 0xBC: 'Œ', 0xBD: 'œ', 0xBE: 'Ÿ',
+}
+
+func latin9ToUTF8(b []byte) []byte {
+	out := make([]byte, 0, len(b)+len(b)/4)
+	for _, c := range b {
+		if r, ok := latin9Diff[c]; ok {
+			out = utf8.AppendRune(out, r)
+		} else {
+			out = utf8.AppendRune(out, rune(c))
+		}
+	}
+	return out
+}
+
+func utf16ToUTF8(b []byte, bigEndian bool) []byte {
+	u := make([]uint16, 0, len(b)/2)
+	for i := 0; i+1 < len(b); i += 2 {
+		if bigEndian {
+			u = append(u, uint16(b[i])<<8|uint16(b[i+1]))
+		} else {
+			u = append(u, uint16(b[i+1])<<8|uint16(b[i]))
+		}
+	}
+	var out []byte
+	for _, r := range utf16.Decode(u) {
+		out = utf8.AppendRune(out, r)
+	}
+	// the prolog may still say encoding="UTF-16"; passthroughCharset ignores it
+	return out
+}
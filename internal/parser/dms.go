@@ -0,0 +1,57 @@
+package parser
+
+import (
+	"encoding/json"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// DMSExt is the extension of DMS media server sidecar files ("<base>.dms.json").
+const DMSExt = ".dms.json"
+
+// DMS is the sidecar format used by the DMS media server.
+type DMS struct {
+	Title     string        `json:"Title"`
+	Resources []DMSResource `json:"Resources"`
+}
+
+// DMSResource describes one way of playing an item.
+type DMSResource struct {
+	MimeType string `json:"MimeType"`
+	Command  string `json:"Command"`
+}
+
+// ParseDMS reads a .dms.json sidecar file.
+func ParseDMS(path string) (DMS, error) {
+	var d DMS
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return d, err
+	}
+	if err := json.Unmarshal(b, &d); err != nil {
+		return d, err
+	}
+	d.Title = strings.TrimSpace(d.Title)
+	return d, nil
+}
+
+// Stream returns the playable source of the first usable resource.
+// Supported commands:
+// - "play-stream <id>": a YouTube video id (as written by our generator)
+// - an absolute http(s) URL, returned as rawURL
+// Returns empty values when no resource is playable.
+func (d DMS) Stream() (streamType, id, rawURL string) {
+	for _, r := range d.Resources {
+		fields := strings.Fields(r.Command)
+		switch {
+		case len(fields) == 2 && fields[0] == "play-stream":
+			return "youtube", fields[1], ""
+		case len(fields) == 1:
+			if u, err := url.Parse(fields[0]); err == nil && (u.Scheme == "http" || u.Scheme == "https") {
+				return "", "", fields[0]
+			}
+		}
+	}
+	return "", "", ""
+}
@@ -0,0 +1,37 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseDMS_PlayStream(t *testing.T) {
+	dir := t.TempDir()
+	p := filepath.Join(dir, "x"+DMSExt)
+	content := `{"Title":"Strange Filters","Resources":[{"MimeType":"video/mp4","Command":"play-stream zbKjqHqy2no"}]}`
+	if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	d, err := ParseDMS(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Title != "Strange Filters" {
+		t.Fatalf("bad title: %q", d.Title)
+	}
+	typ, id, u := d.Stream()
+	if typ != "youtube" || id != "zbKjqHqy2no" || u != "" {
+		t.Fatalf("bad stream: %q %q %q", typ, id, u)
+	}
+}
+
+func TestDMSStream_URLAndUnsupported(t *testing.T) {
+	d := DMS{Resources: []DMSResource{{Command: "ffmpeg -i x"}, {Command: "https://example.com/v.mp4"}}}
+	if _, _, u := d.Stream(); u != "https://example.com/v.mp4" {
+		t.Fatalf("expected URL resource, got %q", u)
+	}
+	if typ, id, u := (DMS{}).Stream(); typ != "" || id != "" || u != "" {
+		t.Fatalf("expected empty stream, got %q %q %q", typ, id, u)
+	}
+}
@@ -1,39 +1,272 @@
 package parser
 
 import (
+	"bytes"
 	"encoding/xml"
 	"os"
+	"strconv"
 	"strings"
+
+	"github.com/claes/ytplv/internal/model"
+)
+
+// NFO root element kinds as reported in Metadata.Kind.
+const (
+	KindMovie      = "movie"
+	KindEpisode    = "episode"
+	KindTVShow     = "tvshow"
+	KindMusicVideo = "musicvideo"
 )
 
+// Metadata holds the fields read from a Kodi-compatible .nfo file.
+type Metadata struct {
+	Kind       string // one of the Kind* constants, derived from the root element
+	Title      string
+	SortTitle  string
+	Plot       string
+	Thumb      string // first <thumb>, typically the poster or video still
+	Tags       []string
+	Premiered  string // <premiered>, or <aired> when premiered is absent
+	Runtime    int    // minutes
+	Genres     []string
+	Studios    []string
+	Directors  []string
+	UniqueIDs  []model.UniqueID
+	Fanart     []string
+	Ratings    []model.Rating
+	PlayCount  int
+	LastPlayed string
+	ShowTitle  string   // episodedetails
+	Season     int      // episodedetails
+	Episode    int      // episodedetails
+	Artists    []string // musicvideo
+	Album      string   // musicvideo
+	Resume     int      // <resume><position>, seconds into the video
+	Total      int      // <resume><total>, seconds
+	ScraperURL string   // URL line following (or replacing) the XML
+}
+
+// movie is the union of the movie, episodedetails, tvshow and musicvideo
+// schemas; they share most element names so a single struct covers all four.
 type movie struct {
-	Title string   `xml:"title"`
-	Plot  string   `xml:"plot"`
-	Thumb string   `xml:"thumb"`
-	Tags  []string `xml:"tag"`
+	XMLName    xml.Name
+	Title      string      `xml:"title"`
+	SortTitle  string      `xml:"sorttitle"`
+	Plot       string      `xml:"plot"`
+	Thumbs     []string    `xml:"thumb"`
+	Tags       []string    `xml:"tag"`
+	Premiered  string      `xml:"premiered"`
+	Aired      string      `xml:"aired"`
+	Runtime    string      `xml:"runtime"`
+	Genres     []string    `xml:"genre"`
+	Studios    []string    `xml:"studio"`
+	Directors  []string    `xml:"director"`
+	UniqueIDs  []nfoUID    `xml:"uniqueid"`
+	Fanart     []string    `xml:"fanart>thumb"`
+	Ratings    []nfoRating `xml:"ratings>rating"`
+	PlayCount  string      `xml:"playcount"`
+	LastPlayed string      `xml:"lastplayed"`
+	ShowTitle  string      `xml:"showtitle"`
+	Season     string      `xml:"season"`
+	Episode    string      `xml:"episode"`
+	Artists    []string    `xml:"artist"`
+	Album      string      `xml:"album"`
+	Resume     nfoResume   `xml:"resume"`
+}
+
+type nfoUID struct {
+	Type    string `xml:"type,attr"`
+	Default string `xml:"default,attr"`
+	Value   string `xml:",chardata"`
+}
+
+type nfoResume struct {
+	Position string `xml:"position"`
+	Total    string `xml:"total"`
+}
+
+type nfoRating struct {
+	Name    string `xml:"name,attr"`
+	Max     string `xml:"max,attr"`
+	Default string `xml:"default,attr"`
+	Value   string `xml:"value"`
+	Votes   string `xml:"votes"`
 }
 
-// ParseNFO parses a Kodi-compatible .nfo XML file and returns key fields.
-func ParseNFO(path string) (title, plot, thumb string, tags []string, err error) {
+// ParseNFO parses a Kodi-compatible .nfo XML file and returns its metadata.
+// Decoding is tolerant: byte-order marks and Latin-1/Windows-1252 content are
+// handled, anything after the root element is ignored except a scraper URL
+// line (Kodi's "XML followed by URL" convention), and a file holding only a
+// URL line yields a minimal record with just ScraperURL set.
+func ParseNFO(path string) (Metadata, error) {
 	b, err := os.ReadFile(path)
 	if err != nil {
-		return "", "", "", nil, err
+		return Metadata{}, err
 	}
-	// Some .nfo files may have HTML entities; xml.Unmarshal handles them.
+	return decodeNFO(toUTF8(b))
+}
+
+func decodeNFO(b []byte) (Metadata, error) {
+	trimmed := bytes.TrimSpace(b)
+	if !bytes.HasPrefix(trimmed, []byte("<")) {
+		if u := firstURL(trimmed); u != "" {
+			return Metadata{Kind: KindMovie, ScraperURL: u}, nil
+		}
+	}
+	d := xml.NewDecoder(bytes.NewReader(b))
+	d.CharsetReader = passthroughCharset
+	d.Strict = false
+	d.Entity = xml.HTMLEntity
+	// Decode reads exactly one element, so trailing content is never parsed.
 	var m movie
-	if err := xml.Unmarshal(b, &m); err != nil {
-		return "", "", "", nil, err
+	if err := d.Decode(&m); err != nil {
+		return Metadata{}, err
 	}
+	md := m.metadata()
+	md.ScraperURL = firstURL(b[d.InputOffset():])
+	return md, nil
+}
+
+// firstURL returns the first line of b that is an http(s) URL.
+func firstURL(b []byte) string {
+	for _, line := range strings.Split(string(b), "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "http://") || strings.HasPrefix(line, "https://") {
+			return line
+		}
+	}
+	return ""
+}
+
+func (m movie) metadata() Metadata {
 	// Normalize whitespace lightly
-	title = strings.TrimSpace(m.Title)
-	plot = strings.TrimSpace(m.Plot)
-	thumb = strings.TrimSpace(m.Thumb)
-	tags = make([]string, 0, len(m.Tags))
-	for _, t := range m.Tags {
-		t = strings.TrimSpace(t)
-		if t != "" {
-			tags = append(tags, t)
+	md := Metadata{
+		Kind:       kindOf(m.XMLName.Local),
+		Title:      strings.TrimSpace(m.Title),
+		SortTitle:  strings.TrimSpace(m.SortTitle),
+		Plot:       strings.TrimSpace(m.Plot),
+		Tags:       trimAll(m.Tags),
+		Premiered:  strings.TrimSpace(m.Premiered),
+		Runtime:    atoi(m.Runtime),
+		Genres:     trimAll(m.Genres),
+		Studios:    trimAll(m.Studios),
+		Directors:  trimAll(m.Directors),
+		Fanart:     trimAll(m.Fanart),
+		PlayCount:  atoi(m.PlayCount),
+		LastPlayed: strings.TrimSpace(m.LastPlayed),
+		ShowTitle:  strings.TrimSpace(m.ShowTitle),
+		Season:     atoi(m.Season),
+		Episode:    atoi(m.Episode),
+		Artists:    trimAll(m.Artists),
+		Album:      strings.TrimSpace(m.Album),
+		Resume:     seconds(m.Resume.Position),
+		Total:      seconds(m.Resume.Total),
+	}
+	if thumbs := trimAll(m.Thumbs); len(thumbs) > 0 {
+		md.Thumb = thumbs[0]
+	}
+	if md.Premiered == "" {
+		md.Premiered = strings.TrimSpace(m.Aired)
+	}
+	for _, u := range m.UniqueIDs {
+		v := strings.TrimSpace(u.Value)
+		if v == "" {
+			continue
+		}
+		md.UniqueIDs = append(md.UniqueIDs, model.UniqueID{
+			Type:    strings.TrimSpace(u.Type),
+			Value:   v,
+			Default: isTrue(u.Default),
+		})
+	}
+	for _, r := range m.Ratings {
+		v, err := strconv.ParseFloat(strings.TrimSpace(r.Value), 64)
+		if err != nil {
+			continue
+		}
+		max, _ := strconv.ParseFloat(strings.TrimSpace(r.Max), 64)
+		md.Ratings = append(md.Ratings, model.Rating{
+			Name:    strings.TrimSpace(r.Name),
+			Value:   v,
+			Max:     max,
+			Votes:   atoi(r.Votes),
+			Default: isTrue(r.Default),
+		})
+	}
+	return md
+}
+
+// kindOf maps an NFO root element name to a Kind* constant.
+func kindOf(root string) string {
+	switch strings.ToLower(root) {
+	case "episodedetails":
+		return KindEpisode
+	case "tvshow":
+		return KindTVShow
+	case "musicvideo":
+		return KindMusicVideo
+	default:
+		return KindMovie
+	}
+}
+
+// Apply copies the metadata fields onto v, leaving source fields untouched.
+func (md Metadata) Apply(v *model.Video) {
+	v.Kind = md.Kind
+	v.Title = md.Title
+	v.SortTitle = md.SortTitle
+	v.Plot = md.Plot
+	v.ThumbURL = md.Thumb
+	v.Tags = md.Tags
+	v.Premiered = md.Premiered
+	v.Runtime = md.Runtime
+	v.Genres = md.Genres
+	v.Studios = md.Studios
+	v.Directors = md.Directors
+	v.UniqueIDs = md.UniqueIDs
+	v.Fanart = md.Fanart
+	v.Ratings = md.Ratings
+	v.PlayCount = md.PlayCount
+	v.LastPlayed = md.LastPlayed
+	v.ShowTitle = md.ShowTitle
+	v.Season = md.Season
+	v.Episode = md.Episode
+	v.Artists = md.Artists
+	v.Album = md.Album
+	v.Resume = md.Resume
+	v.Total = md.Total
+}
+
+// trimAll trims each value and drops empty ones.
+func trimAll(in []string) []string {
+	out := make([]string, 0, len(in))
+	for _, s := range in {
+		s = strings.TrimSpace(s)
+		if s != "" {
+			out = append(out, s)
 		}
 	}
-	return
+	return out
+}
+
+func atoi(s string) int {
+	n, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil {
+		return 0
+	}
+	return n
+}
+
+// seconds parses a Kodi time in (possibly fractional) seconds.
+func seconds(s string) int {
+	f, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil || f < 0 {
+		return 0
+	}
+	return int(f)
+}
+
+func isTrue(s string) bool {
+	b, _ := strconv.ParseBool(strings.TrimSpace(s))
+	return b
 }
@@ -15,20 +15,150 @@ func TestParseNFO_OK(t *testing.T) {
 	if err := os.WriteFile(p, []byte(sampleNFO), 0o644); err != nil {
 		t.Fatal(err)
 	}
-	title, plot, thumb, tags, err := ParseNFO(p)
+	md, err := ParseNFO(p)
 	if err != nil {
 		t.Fatal(err)
 	}
-	if title != "Strange Filters" {
-		t.Fatalf("bad title: %q", title)
+	if md.Title != "Strange Filters" {
+		t.Fatalf("bad title: %q", md.Title)
 	}
-	if plot == "" {
+	if md.Plot == "" {
 		t.Fatalf("empty plot")
 	}
-	if thumb == "" {
+	if md.Thumb == "" {
 		t.Fatalf("empty thumb")
 	}
-	if len(tags) != 2 {
-		t.Fatalf("want 2 tags, got %d", len(tags))
+	if len(md.Tags) != 2 {
+		t.Fatalf("want 2 tags, got %d", len(md.Tags))
+	}
+}
+
+const fullNFO = `<?xml version="1.0" encoding="UTF-8"?>
+<movie>
+  <title>Strange Filters</title>
+  <sorttitle>2025-06-11T14:23:20+00:00 Strange Filters</sorttitle>
+  <aired>2025-06-11</aired>
+  <runtime>14</runtime>
+  <genre>Science</genre><genre>Art</genre>
+  <studio>Posy</studio>
+  <director>Posy</director>
+  <uniqueid type="youtube" default="true">zbKjqHqy2no</uniqueid>
+  <thumb aspect="poster">poster.jpg</thumb>
+  <fanart><thumb>fanart.jpg</thumb></fanart>
+  <ratings><rating name="youtube" max="5" default="true"><value>4.5</value><votes>1200</votes></rating></ratings>
+  <playcount>2</playcount>
+  <lastplayed>2025-07-01 20:15:00</lastplayed>
+  <resume><position>754.500000</position><total>840.000000</total></resume>
+</movie>`
+
+func TestParseNFO_FullSchema(t *testing.T) {
+	dir := t.TempDir()
+	p := filepath.Join(dir, "x.nfo")
+	if err := os.WriteFile(p, []byte(fullNFO), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	md, err := ParseNFO(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if md.SortTitle != "2025-06-11T14:23:20+00:00 Strange Filters" {
+		t.Fatalf("bad sorttitle: %q", md.SortTitle)
+	}
+	if md.Premiered != "2025-06-11" {
+		t.Fatalf("expected aired fallback for premiered, got %q", md.Premiered)
+	}
+	if md.Runtime != 14 || md.PlayCount != 2 {
+		t.Fatalf("bad runtime/playcount: %d/%d", md.Runtime, md.PlayCount)
+	}
+	if len(md.Genres) != 2 || md.Studios[0] != "Posy" || md.Directors[0] != "Posy" {
+		t.Fatalf("bad genre/studio/director: %v %v %v", md.Genres, md.Studios, md.Directors)
+	}
+	if len(md.UniqueIDs) != 1 || md.UniqueIDs[0].Type != "youtube" || !md.UniqueIDs[0].Default {
+		t.Fatalf("bad uniqueid: %+v", md.UniqueIDs)
+	}
+	if md.Thumb != "poster.jpg" || len(md.Fanart) != 1 || md.Fanart[0] != "fanart.jpg" {
+		t.Fatalf("bad thumb/fanart: %q %v", md.Thumb, md.Fanart)
+	}
+	if len(md.Ratings) != 1 || md.Ratings[0].Value != 4.5 || md.Ratings[0].Votes != 1200 {
+		t.Fatalf("bad ratings: %+v", md.Ratings)
+	}
+	if md.LastPlayed != "2025-07-01 20:15:00" {
+		t.Fatalf("bad lastplayed: %q", md.LastPlayed)
+	}
+	if md.Resume != 754 || md.Total != 840 {
+		t.Fatalf("bad resume: %d/%d", md.Resume, md.Total)
+	}
+}
+
+func TestParseNFO_RootKinds(t *testing.T) {
+	dir := t.TempDir()
+	cases := map[string]string{
+		`<episodedetails><title>E</title><showtitle>Show</showtitle><season>2</season><episode>5</episode></episodedetails>`: KindEpisode,
+		`<tvshow><title>Show</title></tvshow>`: KindTVShow,
+		`<musicvideo><title>Song</title><artist>A</artist><album>LP</album></musicvideo>`: KindMusicVideo,
+		`<movie><title>M</title></movie>`: KindMovie,
+	}
+	for content, want := range cases {
+		p := filepath.Join(dir, "x.nfo")
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		md, err := ParseNFO(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if md.Kind != want {
+			t.Fatalf("%s: want kind %q, got %q", content, want, md.Kind)
+		}
+		switch want {
+		case KindEpisode:
+			if md.ShowTitle != "Show" || md.Season != 2 || md.Episode != 5 {
+				t.Fatalf("bad episode fields: %+v", md)
+			}
+		case KindMusicVideo:
+			if len(md.Artists) != 1 || md.Album != "LP" {
+				t.Fatalf("bad musicvideo fields: %+v", md)
+			}
+		}
+	}
+}
+
+func TestParseNFO_TolerantDecoding(t *testing.T) {
+	dir := t.TempDir()
+	cases := []struct {
+		name, content, title, scraper string
+	}{
+		{"bom", "\xef\xbb\xbf<?xml version=\"1.0\" encoding=\"UTF-8\"?><movie><title>Bom</title></movie>", "Bom", ""},
+		{"latin1", "<?xml version=\"1.0\" encoding=\"ISO-8859-1\"?><movie><title>R\xe4ksm\xf6rg\xe5s</title></movie>", "Räksmörgås", ""},
+		{"latin9", "<?xml version=\"1.0\" encoding=\"ISO-8859-15\"?><movie><title>\xa4 \xa6\xa8\xb4\xb8\xbc\xbd\xbe \xe4</title></movie>", "€ ŠšŽžŒœŸ ä", ""},
+		{"undeclared-cp1252", "<movie><title>\x93Quoted\x94 \xe5</title></movie>", "“Quoted” å", ""},
+		{"utf16le", "\xff\xfe<\x00m\x00o\x00v\x00i\x00e\x00>\x00<\x00t\x00i\x00t\x00l\x00e\x00>\x00\xc5\x00<\x00/\x00t\x00i\x00t\x00l\x00e\x00>\x00<\x00/\x00m\x00o\x00v\x00i\x00e\x00>\x00", "Å", ""},
+		{"trailing-url", "<movie><title>T</title></movie>\nhttps://www.themoviedb.org/movie/603\n", "T", "https://www.themoviedb.org/movie/603"},
+		{"html-entity", "<movie><title>A&nbsp;B</title></movie>", "A\u00a0B", ""},
+		{"url-only", "https://www.imdb.com/title/tt0133093/\n", "", "https://www.imdb.com/title/tt0133093/"},
+	}
+	for _, c := range cases {
+		p := filepath.Join(dir, c.name+".nfo")
+		if err := os.WriteFile(p, []byte(c.content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		md, err := ParseNFO(p)
+		if err != nil {
+			t.Fatalf("%s: %v", c.name, err)
+		}
+		if md.Title != c.title || md.ScraperURL != c.scraper {
+			t.Fatalf("%s: got title %q scraper %q, want %q %q", c.name, md.Title, md.ScraperURL, c.title, c.scraper)
+		}
+	}
+}
+
+func TestParseNFO_Garbage(t *testing.T) {
+	dir := t.TempDir()
+	p := filepath.Join(dir, "x.nfo")
+	if err := os.WriteFile(p, []byte("not xml at all"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParseNFO(p); err == nil {
+		t.Fatalf("expected error for non-XML, non-URL content")
 	}
 }
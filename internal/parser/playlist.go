@@ -0,0 +1,38 @@
+package parser
+
+import (
+	"bufio"
+	"os"
+	"strings"
+
+	"github.com/claes/ytplv/internal/source"
+)
+
+// PlaylistExt is the extension of playlist sidecars ("<base>.playlist") that
+// list the videos of a YouTube playlist or channel STRM for offline playback.
+const PlaylistExt = ".playlist"
+
+// ParsePlaylist reads a playlist sidecar. Each line holds a YouTube video id
+// or a YouTube URL; blank lines and lines starting with '#' are skipped.
+func ParsePlaylist(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var ids []string
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if typ, id := source.Parse(line); typ == source.YouTube.Name() {
+			line = id
+		}
+		if line != "" {
+			ids = append(ids, line)
+		}
+	}
+	return ids, sc.Err()
+}
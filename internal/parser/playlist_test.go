@@ -0,0 +1,29 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParsePlaylist(t *testing.T) {
+	dir := t.TempDir()
+	p := filepath.Join(dir, "x"+PlaylistExt)
+	content := "# Posy uploads\nzbKjqHqy2no\n\nhttps://www.youtube.com/watch?v=hiEZikwr60E\nhttps://youtu.be/PkPSDOjhxwM\n"
+	if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	ids, err := ParsePlaylist(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"zbKjqHqy2no", "hiEZikwr60E", "PkPSDOjhxwM"}
+	if len(ids) != len(want) {
+		t.Fatalf("got %v, want %v", ids, want)
+	}
+	for i := range want {
+		if ids[i] != want[i] {
+			t.Fatalf("got %v, want %v", ids, want)
+		}
+	}
+}
@@ -2,44 +2,46 @@ package parser
 
 import (
     "bufio"
-    "net/url"
     "os"
     "strings"
+
+    "github.com/claes/ytplv/internal/source"
 )
 
+// Stream is the parsed content of a .strm file.
+type Stream struct {
+    Type  string // source name, e.g. youtube, svtplay
+    ID    string
+    Start int    // start offset in seconds (t=, start=), 0 if none
+    Line  string // the line as written, trimmed
+}
+
 // ParseStream reads a .strm file and returns the stream type and id.
-// Supported types:
+// The line is matched against the providers in the source registry, e.g.:
 // - youtube: plugin://plugin.video.youtube with query param video_id
 // - svtplay: plugin://plugin.video.svtplay with query param id (URL-encoded path)
+// - http: any other absolute http(s) URL, with the URL as id
 // For unsupported or empty lines, returns ("", "").
 func ParseStream(path string) (streamType, id string, err error) {
+    st, err := ReadStream(path)
+    return st.Type, st.ID, err
+}
+
+// ReadStream reads a .strm file like ParseStream and also returns the start offset.
+func ReadStream(path string) (Stream, error) {
     f, err := os.Open(path)
     if err != nil {
-        return "", "", err
+        return Stream{}, err
     }
     defer f.Close()
 
     r := bufio.NewReader(f)
     line, _ := r.ReadString('\n')
-    line = strings.TrimSpace(line)
-    if line == "" {
-        return "", "", nil
-    }
-    lower := strings.ToLower(line)
-    // Extract raw query string (part after '?'), or whole line as fallback
-    var rawQ string
-    if i := strings.IndexByte(line, '?'); i >= 0 {
-        rawQ = line[i+1:]
-    } else {
-        rawQ = line
-    }
-    q, _ := url.ParseQuery(rawQ)
-    switch {
-    case strings.HasPrefix(lower, "plugin://plugin.video.youtube"):
-        return "youtube", q.Get("video_id"), nil
-    case strings.HasPrefix(lower, "plugin://plugin.video.svtplay"):
-        return "svtplay", q.Get("id"), nil
-    default:
-        return "", "", nil
+    var st Stream
+    st.Line = strings.TrimSpace(line)
+    st.Type, st.ID = source.Parse(line)
+    if st.Type != "" {
+        st.Start = source.StartOffset(line)
     }
+    return st, nil
 }
@@ -3,6 +3,7 @@ package parser
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -18,3 +19,16 @@ func TestParseStream_ExtractsYouTubeID(t *testing.T) {
     if typ != "youtube" { t.Fatalf("want type youtube, got %q", typ) }
     if id != "zbKjqHqy2no" { t.Fatalf("want zbKjqHqy2no, got %q", id) }
 }
+
+func TestReadStream_StartOffset(t *testing.T) {
+    dir := t.TempDir()
+    p := filepath.Join(dir, "x.strm")
+    content := "plugin://plugin.video.youtube/play/?video_id=zbKjqHqy2no&t=90\n"
+    if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+        t.Fatal(err)
+    }
+    st, err := ReadStream(p)
+    if err != nil { t.Fatal(err) }
+    if st.ID != "zbKjqHqy2no" || st.Start != 90 { t.Fatalf("unexpected stream: %+v", st) }
+    if st.Line != strings.TrimSpace(content) { t.Fatalf("unexpected line: %q", st.Line) }
+}
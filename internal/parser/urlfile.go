@@ -2,32 +2,201 @@ package parser
 
 import (
     "bufio"
+    "bytes"
+    "encoding/binary"
+    "encoding/xml"
+    "errors"
     "os"
+    "path/filepath"
     "strings"
+    "unicode/utf16"
 )
 
-// ParseURLFile reads a .url file and returns the first non-empty line trimmed.
-// If the file is empty or only contains whitespace, it returns an empty string.
+// Link file extensions recognised by ParseLink. All of them hold a single URL.
+const (
+    URLExt     = ".url"     // Windows Internet Shortcut, or a bare URL line
+    WeblocExt  = ".webloc"  // macOS plist
+    DesktopExt = ".desktop" // freedesktop Type=Link entry
+)
+
+// IsLinkExt reports whether ext (lower-case, with dot) is a link file extension.
+func IsLinkExt(ext string) bool {
+    switch ext {
+    case URLExt, WeblocExt, DesktopExt:
+        return true
+    }
+    return false
+}
+
+// ParseLink reads a link file of any supported format, chosen by extension.
+func ParseLink(path string) (string, error) {
+    switch strings.ToLower(filepath.Ext(path)) {
+    case WeblocExt:
+        return ParseWebloc(path)
+    case DesktopExt:
+        return ParseDesktop(path)
+    default:
+        return ParseURLFile(path)
+    }
+}
+
+// ParseURLFile reads a .url file. Windows Internet Shortcuts return the URL=
+// value of the [InternetShortcut] section; other files return the first
+// non-empty line trimmed. If nothing is found, it returns an empty string.
 func ParseURLFile(path string) (string, error) {
-    f, err := os.Open(path)
+    b, err := os.ReadFile(path)
     if err != nil {
         return "", err
     }
-    defer f.Close()
-    r := bufio.NewReader(f)
-    for {
-        line, err := r.ReadString('\n')
-        line = strings.TrimSpace(line)
-        if line != "" {
+    b = bytes.TrimPrefix(b, []byte("\xef\xbb\xbf"))
+    if u, ok := iniValue(b, "InternetShortcut", "URL"); ok {
+        return u, nil
+    }
+    sc := bufio.NewScanner(bytes.NewReader(b))
+    for sc.Scan() {
+        if line := strings.TrimSpace(sc.Text()); line != "" {
             return line, nil
         }
-        if err != nil {
-            // EOF or other error; return empty if nothing found yet
-            if line == "" {
-                return "", nil
-            }
-            return line, nil
+    }
+    return "", nil
+}
+
+// ParseDesktop reads a freedesktop .desktop file and returns the URL of a
+// Type=Link entry. Other entry types are an error.
+func ParseDesktop(path string) (string, error) {
+    b, err := os.ReadFile(path)
+    if err != nil {
+        return "", err
+    }
+    if typ, _ := iniValue(b, "Desktop Entry", "Type"); typ != "Link" {
+        return "", errors.New("desktop entry is not Type=Link")
+    }
+    u, _ := iniValue(b, "Desktop Entry", "URL")
+    return u, nil
+}
+
+type plist struct {
+    Dict struct {
+        Items []struct {
+            XMLName xml.Name
+            Value   string `xml:",chardata"`
+        } `xml:",any"`
+    } `xml:"dict"`
+}
+
+// ParseWebloc reads a macOS .webloc file and returns its URL. XML plists are
+// decoded properly; for binary plists the first http(s) string is used.
+func ParseWebloc(path string) (string, error) {
+    b, err := os.ReadFile(path)
+    if err != nil {
+        return "", err
+    }
+    if bytes.HasPrefix(b, []byte("bplist")) {
+        return binaryPlistURL(b), nil
+    }
+    var p plist
+    if err := xml.Unmarshal(b, &p); err != nil {
+        return "", err
+    }
+    items := p.Dict.Items
+    for i := 0; i+1 < len(items); i++ {
+        if items[i].XMLName.Local == "key" && strings.TrimSpace(items[i].Value) == "URL" {
+            return strings.TrimSpace(items[i+1].Value), nil
+        }
+    }
+    return "", nil
+}
+
+// binaryPlistURL returns the first http(s) string object of a bplist00 file,
+// reading the objects through the offset table described by its trailer.
+func binaryPlistURL(b []byte) string {
+    if len(b) < 8+32 {
+        return ""
+    }
+    trailer := b[len(b)-32:]
+    offSize := uint64(trailer[6])
+    count := binary.BigEndian.Uint64(trailer[8:16])
+    table := binary.BigEndian.Uint64(trailer[24:32])
+    if offSize == 0 || offSize > 8 || table > uint64(len(b)-32) || count > (uint64(len(b)-32)-table)/offSize {
+        return ""
+    }
+    for i := uint64(0); i < count; i++ {
+        at := table + i*offSize
+        s, ok := plistString(b, beUint(b[at:at+offSize]))
+        if ok && (strings.HasPrefix(s, "https://") || strings.HasPrefix(s, "http://")) {
+            return s
+        }
+    }
+    return ""
+}
+
+// plistString decodes the bplist string object at off. Its marker is 0x5n
+// (ASCII) or 0x6n (UTF-16BE) with the length n in the low nibble, or with
+// n = 0xf followed by an int object 0x1k holding the length in 2^k bytes.
+func plistString(b []byte, off uint64) (string, bool) {
+    if off >= uint64(len(b)) {
+        return "", false
+    }
+    kind, n := b[off]>>4, uint64(b[off]&0x0f)
+    if kind != 0x5 && kind != 0x6 {
+        return "", false
+    }
+    p := off + 1
+    if n == 0x0f {
+        if p >= uint64(len(b)) || b[p]>>4 != 0x1 || b[p]&0x0f > 3 {
+            return "", false
         }
+        size := uint64(1) << (b[p] & 0x0f)
+        if p+1+size > uint64(len(b)) {
+            return "", false
+        }
+        n = beUint(b[p+1 : p+1+size])
+        p += 1 + size
+    }
+    if kind == 0x6 {
+        n *= 2
+    }
+    if n > uint64(len(b))-p {
+        return "", false
+    }
+    data := b[p : p+n]
+    if kind == 0x5 {
+        return string(data), true
     }
+    units := make([]uint16, len(data)/2)
+    for i := range units {
+        units[i] = binary.BigEndian.Uint16(data[2*i:])
+    }
+    return string(utf16.Decode(units)), true
 }
 
+// beUint reads a big-endian unsigned integer of up to 8 bytes.
+func beUint(b []byte) uint64 {
+    var n uint64
+    for _, c := range b {
+        n = n<<8 | uint64(c)
+    }
+    return n
+}
+
+// iniValue returns key's value in [section] of an INI-style file.
+// Key matching is case-insensitive, as Windows does for .url files.
+func iniValue(b []byte, section, key string) (string, bool) {
+    sc := bufio.NewScanner(bytes.NewReader(b))
+    in := false
+    for sc.Scan() {
+        line := strings.TrimSpace(sc.Text())
+        if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+            in = strings.EqualFold(line[1:len(line)-1], section)
+            continue
+        }
+        if !in {
+            continue
+        }
+        k, v, ok := strings.Cut(line, "=")
+        if ok && strings.EqualFold(strings.TrimSpace(k), key) {
+            return strings.TrimSpace(v), true
+        }
+    }
+    return "", false
+}
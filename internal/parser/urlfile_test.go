@@ -36,3 +36,66 @@ func TestParseURLFile_Empty(t *testing.T) {
     }
 }
 
+func TestParseURLFile_InternetShortcut(t *testing.T) {
+    dir := t.TempDir()
+    p := filepath.Join(dir, "x.url")
+    content := "\xef\xbb\xbf[{000214A0-0000-0000-C000-000000000046}]\r\nProp3=19,11\r\n[InternetShortcut]\r\nIDList=\r\nURL=https://www.youtube.com/watch?v=abc123\r\n"
+    if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+        t.Fatal(err)
+    }
+    u, err := ParseLink(p)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if want := "https://www.youtube.com/watch?v=abc123"; u != want {
+        t.Fatalf("got %q, want %q", u, want)
+    }
+}
+
+func TestParseLink_WeblocAndDesktop(t *testing.T) {
+    dir := t.TempDir()
+    webloc := filepath.Join(dir, "x.webloc")
+    plistXML := `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0"><dict><key>URL</key><string>https://youtu.be/web123</string></dict></plist>`
+    if err := os.WriteFile(webloc, []byte(plistXML), 0o644); err != nil {
+        t.Fatal(err)
+    }
+    bin := filepath.Join(dir, "bin.webloc")
+    // {URL: "https://youtu.be/bin123"} followed by an unrelated ASCII string
+    // object "abc", then the offset table and trailer
+    bplist := "bplist00" +
+        "\xd1\x01\x02" + "SURL" + "_\x10\x17https://youtu.be/bin123" + "Sabc" +
+        "\x08\x0b\x0f\x29" +
+        "\x00\x00\x00\x00\x00\x00\x01\x01" +
+        "\x00\x00\x00\x00\x00\x00\x00\x04" +
+        "\x00\x00\x00\x00\x00\x00\x00\x00" +
+        "\x00\x00\x00\x00\x00\x00\x00\x2d"
+    if err := os.WriteFile(bin, []byte(bplist), 0o644); err != nil {
+        t.Fatal(err)
+    }
+    desktop := filepath.Join(dir, "x.desktop")
+    if err := os.WriteFile(desktop, []byte("[Desktop Entry]\nName=X\nType=Link\nURL=https://youtu.be/desk123\n"), 0o644); err != nil {
+        t.Fatal(err)
+    }
+    app := filepath.Join(dir, "app.desktop")
+    if err := os.WriteFile(app, []byte("[Desktop Entry]\nType=Application\nExec=true\n"), 0o644); err != nil {
+        t.Fatal(err)
+    }
+    for path, want := range map[string]string{
+        webloc:  "https://youtu.be/web123",
+        bin:     "https://youtu.be/bin123",
+        desktop: "https://youtu.be/desk123",
+    } {
+        u, err := ParseLink(path)
+        if err != nil {
+            t.Fatalf("%s: %v", path, err)
+        }
+        if u != want {
+            t.Fatalf("%s: got %q, want %q", path, u, want)
+        }
+    }
+    if _, err := ParseLink(app); err == nil {
+        t.Fatalf("expected error for non-link desktop entry")
+    }
+}
@@ -0,0 +1,274 @@
+package source
+
+import (
+	"net/url"
+	"strings"
+)
+
+// Built-in sources. YouTube and SVT Play are castable; the others can only be
+// opened in a browser until a cast backend exists for them.
+var (
+	YouTube         Source = youtube{}
+	YouTubePlaylist Source = youtubePlaylist{}
+	SVTPlay         Source = svtplay{}
+	Vimeo           Source = vimeo{}
+	Twitch          Source = twitch{}
+	Dailymotion     Source = dailymotion{}
+	HTTP            Source = httpURL{}
+)
+
+func init() {
+	// playlists first: the YouTube add-on prefix matches both
+	Register(YouTubePlaylist)
+	Register(YouTube)
+	Register(SVTPlay)
+	Register(Vimeo)
+	Register(Twitch)
+	Register(Dailymotion)
+}
+
+var openOnly = []Action{ActionOpen}
+
+// youtube covers the YouTube add-on, the Invidious and Piped front-end
+// add-ons (which address the same video ids), and youtube.com/youtu.be URLs.
+type youtube struct{}
+
+func (youtube) Name() string { return "youtube" }
+
+func (youtube) ParseSTRM(line string) (string, bool) {
+	for _, plugin := range []string{"plugin.video.youtube", "plugin.video.invidious", "plugin.video.piped"} {
+		path, q, ok := pluginQuery(line, plugin)
+		if !ok {
+			continue
+		}
+		if id := q.Get("video_id"); id != "" {
+			return id, true
+		}
+		// Piped: plugin://plugin.video.piped/watch/<id>
+		if id, ok := strings.CutPrefix(path, "/watch/"); ok && id != "" {
+			return strings.Trim(id, "/"), true
+		}
+		return "", true
+	}
+	u, host, ok := webURL(line)
+	if !ok {
+		return "", false
+	}
+	switch {
+	case host == "youtu.be":
+		return strings.Trim(u.Path, "/"), true
+	case host == "youtube.com" || strings.HasSuffix(host, ".youtube.com"):
+		if id := u.Query().Get("v"); id != "" {
+			return id, true
+		}
+		if id, ok := strings.CutPrefix(u.Path, "/shorts/"); ok {
+			return strings.Trim(id, "/"), true
+		}
+		return "", true
+	}
+	return "", false
+}
+
+func (youtube) PlayURL(id string) string {
+	return "https://www.youtube.com/watch?v=" + url.QueryEscape(id)
+}
+
+func (youtube) Actions() []Action { return []Action{ActionPlay, ActionQueue, ActionOpen} }
+
+// youtubePlaylist covers YouTube playlists, channels and inline video lists.
+// Ids take one of three forms:
+//   - "PL..." (any playlist id): plugin .../play/?playlist_id=PL... or youtube.com/playlist?list=PL...
+//   - "channel/UC...": plugin .../channel/UC.../ or youtube.com/channel/UC...
+//   - "videos/a,b,c": plugin .../play/?video_ids=a,b,c
+type youtubePlaylist struct{}
+
+func (youtubePlaylist) Name() string { return "youtube-playlist" }
+
+func (youtubePlaylist) ParseSTRM(line string) (string, bool) {
+	if path, q, ok := pluginQuery(line, "plugin.video.youtube"); ok {
+		if id := q.Get("playlist_id"); id != "" {
+			return id, true
+		}
+		if _, pl, ok := strings.Cut(path, "/playlist/"); ok && strings.Trim(pl, "/") != "" {
+			return strings.Trim(pl, "/"), true
+		}
+		if ch, ok := strings.CutPrefix(path, "/channel/"); ok {
+			ch, _, _ = strings.Cut(ch, "/")
+			if ch != "" {
+				return "channel/" + ch, true
+			}
+		}
+		if ids := q.Get("video_ids"); ids != "" {
+			return "videos/" + ids, true
+		}
+		return "", false
+	}
+	u, host, ok := webURL(line)
+	if !ok || (host != "youtube.com" && !strings.HasSuffix(host, ".youtube.com")) {
+		return "", false
+	}
+	switch {
+	case u.Path == "/playlist" && u.Query().Get("list") != "":
+		return u.Query().Get("list"), true
+	case strings.HasPrefix(u.Path, "/channel/"):
+		ch, _, _ := strings.Cut(strings.TrimPrefix(u.Path, "/channel/"), "/")
+		if ch != "" {
+			return "channel/" + ch, true
+		}
+	}
+	return "", false
+}
+
+func (youtubePlaylist) PlayURL(id string) string {
+	if ch, ok := strings.CutPrefix(id, "channel/"); ok {
+		return "https://www.youtube.com/channel/" + url.PathEscape(ch)
+	}
+	if ids, ok := strings.CutPrefix(id, "videos/"); ok {
+		return "https://www.youtube.com/watch_videos?video_ids=" + url.QueryEscape(ids)
+	}
+	return "https://www.youtube.com/playlist?list=" + url.QueryEscape(id)
+}
+
+func (youtubePlaylist) Actions() []Action { return []Action{ActionPlay, ActionQueue, ActionOpen} }
+
+// InlineVideoIDs returns the video ids embedded in a youtube-playlist id of
+// the "videos/a,b,c" form, or nil for playlist and channel ids.
+func InlineVideoIDs(id string) []string {
+	ids, ok := strings.CutPrefix(id, "videos/")
+	if !ok {
+		return nil
+	}
+	var out []string
+	for _, v := range strings.Split(ids, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// svtplay ids are site paths such as /video/abc123.
+type svtplay struct{}
+
+func (svtplay) Name() string { return "svtplay" }
+
+func (svtplay) ParseSTRM(line string) (string, bool) {
+	if _, q, ok := pluginQuery(line, "plugin.video.svtplay"); ok {
+		return q.Get("id"), true
+	}
+	if u, host, ok := webURL(line); ok && host == "svtplay.se" {
+		return u.Path, true
+	}
+	return "", false
+}
+
+func (svtplay) PlayURL(id string) string {
+	return "https://www.svtplay.se" + id + "?video=visa"
+}
+
+func (svtplay) Actions() []Action { return []Action{ActionPlay, ActionOpen} }
+
+type vimeo struct{}
+
+func (vimeo) Name() string { return "vimeo" }
+
+func (vimeo) ParseSTRM(line string) (string, bool) {
+	if _, q, ok := pluginQuery(line, "plugin.video.vimeo"); ok {
+		return q.Get("video_id"), true
+	}
+	if u, host, ok := webURL(line); ok && host == "vimeo.com" {
+		return strings.Trim(u.Path, "/"), true
+	}
+	return "", false
+}
+
+func (vimeo) PlayURL(id string) string { return "https://vimeo.com/" + id }
+
+func (vimeo) Actions() []Action { return openOnly }
+
+// twitch ids are either a VOD ("v" followed by digits) or a channel name.
+type twitch struct{}
+
+func (twitch) Name() string { return "twitch" }
+
+func (twitch) ParseSTRM(line string) (string, bool) {
+	if _, q, ok := pluginQuery(line, "plugin.video.twitch"); ok {
+		if id := q.Get("video_id"); id != "" {
+			if !strings.HasPrefix(id, "v") {
+				id = "v" + id
+			}
+			return id, true
+		}
+		return q.Get("channel_name"), true
+	}
+	if u, host, ok := webURL(line); ok && host == "twitch.tv" {
+		if id, ok := strings.CutPrefix(u.Path, "/videos/"); ok {
+			return "v" + strings.Trim(id, "/"), true
+		}
+		return strings.Trim(u.Path, "/"), true
+	}
+	return "", false
+}
+
+func (twitch) PlayURL(id string) string {
+	if isTwitchVOD(id) {
+		return "https://www.twitch.tv/videos/" + id[1:]
+	}
+	return "https://www.twitch.tv/" + id
+}
+
+func (twitch) Actions() []Action { return openOnly }
+
+func isTwitchVOD(id string) bool {
+	if len(id) < 2 || id[0] != 'v' {
+		return false
+	}
+	for _, c := range id[1:] {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+type dailymotion struct{}
+
+func (dailymotion) Name() string { return "dailymotion" }
+
+func (dailymotion) ParseSTRM(line string) (string, bool) {
+	if _, q, ok := pluginQuery(line, "plugin.video.dailymotion_com"); ok {
+		return q.Get("url"), true
+	}
+	if u, host, ok := webURL(line); ok {
+		switch {
+		case host == "dailymotion.com":
+			if id, ok := strings.CutPrefix(u.Path, "/video/"); ok {
+				return strings.Trim(id, "/"), true
+			}
+			return "", true
+		case host == "dai.ly":
+			return strings.Trim(u.Path, "/"), true
+		}
+	}
+	return "", false
+}
+
+func (dailymotion) PlayURL(id string) string { return "https://www.dailymotion.com/video/" + id }
+
+func (dailymotion) Actions() []Action { return openOnly }
+
+// httpURL is the fallback for STRMs holding a plain web URL; the id is the URL.
+type httpURL struct{}
+
+func (httpURL) Name() string { return "http" }
+
+func (httpURL) ParseSTRM(line string) (string, bool) {
+	if _, _, ok := webURL(line); ok {
+		return line, true
+	}
+	return "", false
+}
+
+func (httpURL) PlayURL(id string) string { return id }
+
+func (httpURL) Actions() []Action { return openOnly }
@@ -0,0 +1,137 @@
+// Package source knows how to recognise the stream references found in .strm
+// and .url files and how to turn them into canonical play URLs.
+package source
+
+import (
+	"net/url"
+	"strings"
+)
+
+// Action is something the UI may offer for an item of a given source.
+type Action string
+
+const (
+	ActionPlay  Action = "play"  // cast to the configured device
+	ActionQueue Action = "queue" // add to the device's queue
+	ActionOpen  Action = "open"  // open the canonical URL in a browser
+)
+
+// Source is a stream provider such as YouTube or SVT Play.
+type Source interface {
+	// Name is the stream type stored in model.Video.Type.
+	Name() string
+	// ParseSTRM extracts the provider id from a .strm line (a plugin:// URL
+	// or a plain web URL). ok is false when the line is not for this source.
+	ParseSTRM(line string) (id string, ok bool)
+	// PlayURL builds the canonical web URL for id.
+	PlayURL(id string) string
+	// Actions lists what the UI can do with items of this source.
+	Actions() []Action
+}
+
+// registry holds the known sources in match order. The generic http source
+// is not part of it; it is the fallback for unmatched web URLs.
+var registry []Source
+
+// Register adds s to the registry. Sources registered earlier match first.
+func Register(s Source) {
+	registry = append(registry, s)
+}
+
+// Lookup returns the source named name, or nil if it is unknown.
+func Lookup(name string) Source {
+	for _, s := range registry {
+		if s.Name() == name {
+			return s
+		}
+	}
+	if name == HTTP.Name() {
+		return HTTP
+	}
+	return nil
+}
+
+// Parse identifies the source of a .strm line and returns its type and id.
+// Unrecognised lines yield empty strings.
+func Parse(line string) (streamType, id string) {
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return "", ""
+	}
+	for _, s := range registry {
+		if id, ok := s.ParseSTRM(line); ok {
+			return s.Name(), id
+		}
+	}
+	if id, ok := HTTP.ParseSTRM(line); ok {
+		return HTTP.Name(), id
+	}
+	return "", ""
+}
+
+// ForURL returns the source of an absolute web URL, falling back to HTTP.
+func ForURL(u string) Source {
+	for _, s := range registry {
+		if _, ok := s.ParseSTRM(u); ok {
+			return s
+		}
+	}
+	return HTTP
+}
+
+// Supports reports whether s offers action a.
+func Supports(s Source, a Action) bool {
+	if s == nil {
+		return false
+	}
+	for _, x := range s.Actions() {
+		if x == a {
+			return true
+		}
+	}
+	return false
+}
+
+// Resolve returns the source and canonical play URL for an item. An explicit
+// url (from a .url file) takes precedence over typ and id.
+func Resolve(typ, id, rawURL string) (Source, string) {
+	if rawURL != "" {
+		return ForURL(rawURL), rawURL
+	}
+	s := Lookup(typ)
+	if s == nil || id == "" {
+		return nil, ""
+	}
+	return s, s.PlayURL(id)
+}
+
+// pluginQuery parses a plugin://<plugin>/... line and returns its path and
+// query. ok is false when the line does not address plugin.
+func pluginQuery(line, plugin string) (path string, q url.Values, ok bool) {
+	prefix := "plugin://" + plugin
+	if !strings.HasPrefix(strings.ToLower(line), prefix) {
+		return "", nil, false
+	}
+	rest := line[len(prefix):]
+	if rest != "" && rest[0] != '/' && rest[0] != '?' {
+		return "", nil, false // e.g. plugin.video.youtube2
+	}
+	path = rest
+	var rawQ string
+	if i := strings.IndexByte(rest, '?'); i >= 0 {
+		path, rawQ = rest[:i], rest[i+1:]
+	}
+	q, _ = url.ParseQuery(rawQ)
+	return path, q, true
+}
+
+// webURL parses line as an absolute http(s) URL and returns it with a
+// lower-cased host without "www.". ok is false for anything else.
+func webURL(line string) (u *url.URL, host string, ok bool) {
+	u, err := url.Parse(line)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, "", false
+	}
+	host = strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	return u, host, true
+}
@@ -0,0 +1,70 @@
+package source
+
+import "testing"
+
+func TestParse_Providers(t *testing.T) {
+	cases := []struct {
+		line, typ, id, playURL string
+	}{
+		{"plugin://plugin.video.youtube/play/?video_id=zbKjqHqy2no", "youtube", "zbKjqHqy2no", "https://www.youtube.com/watch?v=zbKjqHqy2no"},
+		{"plugin://plugin.video.invidious/?action=play_video&video_id=abc", "youtube", "abc", "https://www.youtube.com/watch?v=abc"},
+		{"plugin://plugin.video.piped/watch/abc", "youtube", "abc", "https://www.youtube.com/watch?v=abc"},
+		{"https://youtu.be/abc", "youtube", "abc", "https://www.youtube.com/watch?v=abc"},
+		{"plugin://plugin.video.svtplay/?id=%2Fvideo%2Fx1", "svtplay", "/video/x1", "https://www.svtplay.se/video/x1?video=visa"},
+		{"plugin://plugin.video.vimeo/play/?video_id=76979871", "vimeo", "76979871", "https://vimeo.com/76979871"},
+		{"plugin://plugin.video.twitch/?mode=play&video_id=123", "twitch", "v123", "https://www.twitch.tv/videos/123"},
+		{"https://www.twitch.tv/somechannel", "twitch", "somechannel", "https://www.twitch.tv/somechannel"},
+		{"plugin://plugin.video.dailymotion_com/?mode=playVideo&url=x7abc", "dailymotion", "x7abc", "https://www.dailymotion.com/video/x7abc"},
+		{"plugin://plugin.video.youtube/play/?playlist_id=PLabc", "youtube-playlist", "PLabc", "https://www.youtube.com/playlist?list=PLabc"},
+		{"plugin://plugin.video.youtube/channel/UCxyz/", "youtube-playlist", "channel/UCxyz", "https://www.youtube.com/channel/UCxyz"},
+		{"plugin://plugin.video.youtube/channel/UCxyz/playlist/PLdef/", "youtube-playlist", "PLdef", "https://www.youtube.com/playlist?list=PLdef"},
+		{"plugin://plugin.video.youtube/play/?video_ids=a,b", "youtube-playlist", "videos/a,b", "https://www.youtube.com/watch_videos?video_ids=a%2Cb"},
+		{"https://www.youtube.com/playlist?list=PLabc", "youtube-playlist", "PLabc", "https://www.youtube.com/playlist?list=PLabc"},
+		{"https://www.youtube.com/watch?v=abc&list=PLabc", "youtube", "abc", "https://www.youtube.com/watch?v=abc"},
+		{"https://example.com/video.mp4", "http", "https://example.com/video.mp4", "https://example.com/video.mp4"},
+	}
+	for _, c := range cases {
+		typ, id := Parse(c.line)
+		if typ != c.typ || id != c.id {
+			t.Fatalf("%s: got (%q, %q), want (%q, %q)", c.line, typ, id, c.typ, c.id)
+		}
+		if got := Lookup(typ).PlayURL(id); got != c.playURL {
+			t.Fatalf("%s: play url %q, want %q", c.line, got, c.playURL)
+		}
+	}
+}
+
+func TestParse_Unsupported(t *testing.T) {
+	for _, line := range []string{"", "plugin://plugin.video.unknown/?id=1", "plugin://plugin.video.youtube2/?video_id=x"} {
+		if typ, id := Parse(line); typ != "" || id != "" {
+			t.Fatalf("%q: expected no match, got (%q, %q)", line, typ, id)
+		}
+	}
+}
+
+func TestResolve_Actions(t *testing.T) {
+	s, u := Resolve("", "", "https://www.youtube.com/watch?v=abc")
+	if s != YouTube || u != "https://www.youtube.com/watch?v=abc" {
+		t.Fatalf("url file: got %v %q", s, u)
+	}
+	if !Supports(s, ActionQueue) {
+		t.Fatalf("youtube should support queue")
+	}
+	s, _ = Resolve("svtplay", "/video/x", "")
+	if !Supports(s, ActionPlay) || Supports(s, ActionQueue) {
+		t.Fatalf("svtplay actions: %v", s.Actions())
+	}
+	s, _ = Resolve("vimeo", "1", "")
+	if Supports(s, ActionPlay) || !Supports(s, ActionOpen) {
+		t.Fatalf("vimeo actions: %v", s.Actions())
+	}
+}
+
+func TestInlineVideoIDs(t *testing.T) {
+	if got := InlineVideoIDs("videos/a, b,,c"); len(got) != 3 || got[1] != "b" {
+		t.Fatalf("unexpected ids: %v", got)
+	}
+	if got := InlineVideoIDs("PLabc"); got != nil {
+		t.Fatalf("expected nil for playlist id, got %v", got)
+	}
+}
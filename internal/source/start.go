@@ -0,0 +1,74 @@
+package source
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// startParams are the query parameters that carry a start offset, in order
+// of preference.
+var startParams = []string{"t", "start", "time_continue"}
+
+// StartOffset returns the start offset in seconds encoded in a .strm line or
+// web URL ("t=90", "t=1m30s", "start=90", or a "#t=90" fragment). Returns 0
+// when there is none.
+func StartOffset(line string) int {
+	line = strings.TrimSpace(line)
+	rawQ := ""
+	if i := strings.IndexByte(line, '?'); i >= 0 {
+		rawQ = line[i+1:]
+	}
+	frag := ""
+	if i := strings.IndexByte(rawQ, '#'); i >= 0 {
+		rawQ, frag = rawQ[:i], rawQ[i+1:]
+	} else if i := strings.IndexByte(line, '#'); i >= 0 {
+		frag = line[i+1:]
+	}
+	q, _ := url.ParseQuery(rawQ)
+	for _, p := range startParams {
+		if n := parseOffset(q.Get(p)); n > 0 {
+			return n
+		}
+	}
+	fq, _ := url.ParseQuery(frag)
+	return parseOffset(fq.Get("t"))
+}
+
+// parseOffset accepts plain seconds ("90", "90s") or a duration made of
+// h/m/s parts ("1m30s", "1h2m").
+func parseOffset(v string) int {
+	v = strings.TrimSpace(strings.ToLower(v))
+	if v == "" {
+		return 0
+	}
+	if n, err := strconv.Atoi(strings.TrimSuffix(v, "s")); err == nil {
+		if n < 0 {
+			return 0
+		}
+		return n
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d < 0 {
+		return 0
+	}
+	return int(d / time.Second)
+}
+
+// WithStart returns a YouTube URL with its start offset set to sec seconds.
+// Other URLs, and sec <= 0, return rawURL unchanged.
+func WithStart(rawURL string, sec int) string {
+	if sec <= 0 {
+		return rawURL
+	}
+	u, host, ok := webURL(rawURL)
+	if !ok || (host != "youtu.be" && host != "youtube.com" && !strings.HasSuffix(host, ".youtube.com")) {
+		return rawURL
+	}
+	q := u.Query()
+	q.Del("start")
+	q.Set("t", strconv.Itoa(sec))
+	u.RawQuery = q.Encode()
+	return u.String()
+}
@@ -0,0 +1,32 @@
+package source
+
+import "testing"
+
+func TestStartOffset(t *testing.T) {
+	cases := map[string]int{
+		"plugin://plugin.video.youtube/play/?video_id=abc&t=90":     90,
+		"plugin://plugin.video.youtube/play/?video_id=abc&start=15": 15,
+		"https://youtu.be/abc?t=1m30s":                              90,
+		"https://www.youtube.com/watch?v=abc&t=45s":                 45,
+		"https://vimeo.com/1#t=12":                                  12,
+		"https://www.youtube.com/watch?v=abc":                       0,
+		"https://www.youtube.com/watch?v=abc&t=bogus":               0,
+	}
+	for line, want := range cases {
+		if got := StartOffset(line); got != want {
+			t.Fatalf("%s: got %d, want %d", line, got, want)
+		}
+	}
+}
+
+func TestWithStart(t *testing.T) {
+	if got := WithStart("https://www.youtube.com/watch?v=abc", 90); got != "https://www.youtube.com/watch?t=90&v=abc" {
+		t.Fatalf("unexpected url: %q", got)
+	}
+	if got := WithStart("https://youtu.be/abc?t=5", 90); got != "https://youtu.be/abc?t=90" {
+		t.Fatalf("unexpected url: %q", got)
+	}
+	if got := WithStart("https://www.svtplay.se/video/x", 90); got != "https://www.svtplay.se/video/x" {
+		t.Fatalf("non-youtube url changed: %q", got)
+	}
+}
@@ -6,6 +6,7 @@ import (
     "fmt"
     "io"
     "os"
+    "time"
 )
 
 const (
@@ -13,10 +14,27 @@ const (
     filePerm = 0o600
 )
 
-// State represents the persisted application state.
-// For now, it only stores the ytcast code/device.
+// State represents the persisted application state: the ytcast code/device,
+// what was recently played or queued and the state of cast backends (such as
+// paired YouTube screens).
 type State struct {
-    YtcastCode string `json:"ytcast_code"`
+    YtcastCode string                     `json:"ytcast_code"`
+    Activity   map[string]Activity        `json:"activity,omitempty"` // keyed by source type and id, or URL
+    Casters    map[string]json.RawMessage `json:"casters,omitempty"`  // keyed by caster name
+}
+
+// Activity records when an item was last played or queued through castweb.
+type Activity struct {
+    Played time.Time `json:"played,omitzero"`
+    Queued time.Time `json:"queued,omitzero"`
+}
+
+// Last returns the most recent of the played and queued times.
+func (a Activity) Last() time.Time {
+    if a.Queued.After(a.Played) {
+        return a.Queued
+    }
+    return a.Played
 }
 
 // LoadState reads state from path.